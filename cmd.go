@@ -4,9 +4,11 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"go.uber.org/fx"
 )
 
@@ -14,12 +16,50 @@ import (
 // and returns a *cobra.Command
 type CommandConstructor any
 
-var root *cobra.Command
+// CommandOption configures the root command of CommandModule.
+// It can be passed to CommandModule along with the command constructors
+type CommandOption func(root *cobra.Command)
+
+// WithName sets the binary name shown in the help output. Defaults to the name of the executable
+func WithName(name string) CommandOption {
+	return func(root *cobra.Command) {
+		root.Use = name
+	}
+}
+
+// WithVersion sets the version and adds the --version flag to the root command
+func WithVersion(version string) CommandOption {
+	return func(root *cobra.Command) {
+		root.Version = version
+	}
+}
+
+// WithPersistentFlags registers the flags available to every command
+func WithPersistentFlags(register func(flags *pflag.FlagSet)) CommandOption {
+	return func(root *cobra.Command) {
+		register(root.PersistentFlags())
+	}
+}
+
+// WithPersistentPreRun registers a hook that runs before every command
+func WithPersistentPreRun(hook func(cmd *cobra.Command, args []string) error) CommandOption {
+	return func(root *cobra.Command) {
+		root.PersistentPreRunE = hook
+	}
+}
+
+// WithPersistentPostRun registers a hook that runs after every command
+func WithPersistentPostRun(hook func(cmd *cobra.Command, args []string) error) CommandOption {
+	return func(root *cobra.Command) {
+		root.PersistentPostRunE = hook
+	}
+}
 
 // CommandModule is a module that registers your command to the root command.
+// Every CommandModule has its own root command, configured by the CommandOption among the cmds
 func CommandModule(desc string, cmds ...CommandConstructor) fx.Option {
-	root = &cobra.Command{
-		Use:   "cmd",
+	root := &cobra.Command{
+		Use:   filepath.Base(os.Args[0]),
 		Short: desc,
 	}
 
@@ -41,6 +81,11 @@ func CommandModule(desc string, cmds ...CommandConstructor) fx.Option {
 
 	fxOptions := []fx.Option{fx.Invoke(startCmd)}
 	for _, cmd := range cmds {
+		if option, ok := cmd.(CommandOption); ok {
+			option(root)
+			continue
+		}
+
 		fxOptions = append(fxOptions,
 			fx.Module("command.registry",
 				fx.Provide(fx.Private, cmd),
@@ -51,3 +96,92 @@ func CommandModule(desc string, cmds ...CommandConstructor) fx.Option {
 
 	return fx.Module("root", fxOptions...)
 }
+
+// LazyCommand registers a command whose dependencies are only constructed and started when the command runs,
+// so the other commands don't need them, e.g. the database is not required to run a `hello` command.
+// The use and short are shown in the help output without constructing the command:
+//
+//	gema.CommandModule("Command line application",
+//		helloWorld,
+//		gema.LazyCommand("migrate", "Run database migration",
+//			gema.MigrationCommand(migrationFs, "migrations"),
+//			gema.DatabaseModule(env.DB_URL),
+//		),
+//	)
+func LazyCommand(use, short string, constructor CommandConstructor, deps ...fx.Option) CommandConstructor {
+	return func() *cobra.Command {
+		noop := func(*cobra.Command, []string) error { return nil }
+		placeholder := &cobra.Command{
+			Use:                use,
+			Short:              short,
+			DisableFlagParsing: true,
+			SilenceErrors:      true,
+			SilenceUsage:       true,
+
+			// the persistent hooks of the parents run once for the actual command
+			PersistentPreRunE:  noop,
+			PersistentPostRunE: noop,
+		}
+
+		placeholder.RunE = func(cmd *cobra.Command, args []string) error {
+			var actual *cobra.Command
+			app := fx.New(
+				fx.NopLogger,
+				fx.Options(deps...),
+				fx.Provide(constructor),
+				fx.Populate(&actual),
+			)
+
+			if err := app.Err(); err != nil {
+				return err
+			}
+
+			// printing the help doesn't need the dependencies to be started
+			if !isHelp(args) {
+				startCtx, cancel := context.WithTimeout(cmd.Context(), app.StartTimeout())
+				defer cancel()
+
+				if err := app.Start(startCtx); err != nil {
+					return err
+				}
+
+				defer func() {
+					stopCtx, cancel := context.WithTimeout(context.Background(), app.StopTimeout())
+					defer cancel()
+					app.Stop(stopCtx)
+				}()
+			}
+
+			// swap the placeholder with the actual command and execute it again from the root,
+			// so the flags and the hooks of the parents are applied as usual
+			path := []string{actual.Name()}
+			for parent := cmd.Parent(); parent.HasParent(); parent = parent.Parent() {
+				path = append([]string{parent.Name()}, path...)
+			}
+
+			root := cmd.Root()
+			parent := cmd.Parent()
+			parent.RemoveCommand(cmd)
+			parent.AddCommand(actual)
+			defer func() {
+				parent.RemoveCommand(actual)
+				parent.AddCommand(cmd)
+			}()
+
+			root.SetArgs(append(path, args...))
+			return root.ExecuteContext(cmd.Context())
+		}
+
+		return placeholder
+	}
+}
+
+func isHelp(args []string) bool {
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,321 @@
+package gema
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// ErrEnvRequired is reported when a required environment variable is not set
+var ErrEnvRequired = errors.New("required but not set")
+
+// EnvError describes an environment variable that is missing or can not be parsed
+type EnvError struct {
+	Key   string
+	Value string
+	Err   error
+}
+
+func (e *EnvError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%q: %v", e.Value, e.Err)
+	}
+
+	if e.Value == "" {
+		return fmt.Sprintf("%s: %v", e.Key, e.Err)
+	}
+
+	return fmt.Sprintf("%s=%q: %v", e.Key, e.Value, e.Err)
+}
+
+func (e *EnvError) Unwrap() error {
+	return e.Err
+}
+
+// ByteSize is a size in bytes. Config fields of this type are parsed
+// like Parser.Bytes, so values such as "10MB" are accepted
+type ByteSize int64
+
+var (
+	timeType     = reflect.TypeFor[time.Time]()
+	durationType = reflect.TypeFor[time.Duration]()
+	urlType      = reflect.TypeFor[url.URL]()
+	urlPtrType   = reflect.TypeFor[*url.URL]()
+	byteSizeType = reflect.TypeFor[ByteSize]()
+)
+
+// LoadConfig fills the target struct from the environment variables using the struct tags:
+//
+//	type Config struct {
+//		Port     int    `env:"APP_PORT" default:"8001"`
+//		DBUrl    string `env:"DB_URL" required:"true"`
+//		Mailer   Mailer `prefix:"MAILER_"`
+//	}
+//
+// Nested structs are loaded recursively, and their keys are prefixed with the `prefix` tag.
+// Slices are split by the `sep` tag (defaults to ","), maps are parsed from "k=v,k2=v2",
+// and time.Time uses the `layout` tag (defaults to time.DateOnly).
+// Fields tagged with `secret:"true"` are masked by ConfigCommand.
+// Instead of silently falling back to the zero value, LoadConfig returns a single error
+// listing every missing or unparsable variable. Each of them can be inspected as *EnvError
+func LoadConfig(target any) error {
+	val := reflect.ValueOf(target)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("[Gema] config target must be a non-nil pointer to a struct, got %T", target)
+	}
+
+	for _, field := range configFields(val.Elem().Type(), "") {
+		registerConfig(field)
+	}
+
+	return errors.Join(loadStruct(val.Elem(), "")...)
+}
+
+func loadStruct(val reflect.Value, prefix string) []error {
+	var errs []error
+
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldVal := val.Field(i)
+		key, hasKey := field.Tag.Lookup("env")
+		if !hasKey {
+			if nested, ok := nestedStruct(fieldVal); ok {
+				errs = append(errs, loadStruct(nested, prefix+field.Tag.Get("prefix"))...)
+			}
+
+			continue
+		}
+
+		key = prefix + key
+		value := Env(key).String()
+		if value == "" {
+			value = field.Tag.Get("default")
+		}
+
+		if value == "" {
+			if field.Tag.Get("required") == "true" {
+				errs = append(errs, &EnvError{Key: key, Err: ErrEnvRequired})
+			}
+
+			continue
+		}
+
+		if err := setField(fieldVal, value, field.Tag); err != nil {
+			errs = append(errs, &EnvError{Key: key, Value: value, Err: err})
+		}
+	}
+
+	return errs
+}
+
+// configField is an environment variable declared by a config struct
+type configField struct {
+	key      string
+	defaults string
+	secret   bool
+}
+
+// configFields lists the environment variables declared by the config struct type
+func configFields(typ reflect.Type, prefix string) []configField {
+	var fields []configField
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		key, hasKey := field.Tag.Lookup("env")
+		if hasKey {
+			fields = append(fields, configField{
+				key:      prefix + key,
+				defaults: field.Tag.Get("default"),
+				secret:   field.Tag.Get("secret") == "true",
+			})
+			continue
+		}
+
+		nested := field.Type
+		if nested.Kind() == reflect.Ptr {
+			nested = nested.Elem()
+		}
+
+		if nested.Kind() == reflect.Struct && nested != timeType && nested != urlType {
+			fields = append(fields, configFields(nested, prefix+field.Tag.Get("prefix"))...)
+		}
+	}
+
+	return fields
+}
+
+// nestedStruct returns the struct that should be loaded recursively, allocating nil pointers on the way
+func nestedStruct(val reflect.Value) (reflect.Value, bool) {
+	if val.Kind() == reflect.Ptr && val.Type().Elem().Kind() == reflect.Struct {
+		if val.IsNil() {
+			val.Set(reflect.New(val.Type().Elem()))
+		}
+
+		val = val.Elem()
+	}
+
+	if val.Kind() != reflect.Struct || val.Type() == timeType || val.Type() == urlType {
+		return val, false
+	}
+
+	return val, true
+}
+
+func setField(val reflect.Value, value string, tag reflect.StructTag) error {
+	switch val.Type() {
+	case timeType:
+		layout := tag.Get("layout")
+		if layout == "" {
+			layout = time.DateOnly
+		}
+
+		t, err := parseTimeLayout(layout)(value)
+		if err != nil {
+			return err
+		}
+
+		val.Set(reflect.ValueOf(t))
+		return nil
+
+	case urlType, urlPtrType:
+		u, err := parseURL(value)
+		if err != nil {
+			return err
+		}
+
+		if val.Type() == urlType {
+			val.Set(reflect.ValueOf(*u))
+		} else {
+			val.Set(reflect.ValueOf(u))
+		}
+
+		return nil
+
+	case byteSizeType:
+		size, err := parseBytes(value)
+		if err != nil {
+			return err
+		}
+
+		val.SetInt(size)
+		return nil
+
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		val.SetInt(int64(d))
+		return nil
+	}
+
+	switch val.Kind() {
+	case reflect.String:
+		val.SetString(value)
+
+	// the kinds supported by Parser are converted the same way, so a variable
+	// resolves to the same value through Env and LoadConfig
+	case reflect.Bool:
+		b, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+
+		val.SetBool(b)
+
+	case reflect.Int:
+		num, err := parseInt(value)
+		if err != nil {
+			return err
+		}
+
+		val.SetInt(int64(num))
+
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		num, err := strconv.ParseInt(value, 10, val.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		val.SetInt(num)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		num, err := strconv.ParseUint(value, 10, val.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		val.SetUint(num)
+
+	case reflect.Float64:
+		num, err := parseFloat(value)
+		if err != nil {
+			return err
+		}
+
+		val.SetFloat(num)
+
+	case reflect.Float32:
+		num, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return err
+		}
+
+		val.SetFloat(num)
+
+	case reflect.Slice:
+		sep := tag.Get("sep")
+		if sep == "" {
+			sep = ","
+		}
+
+		items := splitList(value, sep)
+		slice := reflect.MakeSlice(val.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setField(slice.Index(i), item, tag); err != nil {
+				return err
+			}
+		}
+
+		val.Set(slice)
+
+	case reflect.Map:
+		if val.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", val.Type())
+		}
+
+		pairs, err := parseMap(value)
+		if err != nil {
+			return err
+		}
+
+		m := reflect.MakeMapWithSize(val.Type(), len(pairs))
+		for k, v := range pairs {
+			item := reflect.New(val.Type().Elem()).Elem()
+			if err := setField(item, v, tag); err != nil {
+				return err
+			}
+
+			m.SetMapIndex(reflect.ValueOf(k).Convert(val.Type().Key()), item)
+		}
+
+		val.Set(m)
+
+	default:
+		return fmt.Errorf("unsupported type %s", val.Type())
+	}
+
+	return nil
+}
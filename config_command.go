@@ -0,0 +1,86 @@
+package gema
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+const secretMask = "*****"
+
+// secretHints are the key fragments considered secret even without the `secret` tag
+var secretHints = []string{"PASS", "SECRET", "TOKEN", "PRIVATE", "API_KEY", "CREDENTIAL"}
+
+// ConfigCommand prints every configuration key resolved through gema.Env or gema.LoadConfig,
+// along with its resolved value, its default and its source. Keys tagged with `secret:"true"`
+// or named like a secret (e.g. MAILER_PASS) are masked, and so are the passwords inside URLs (e.g. DB_URL)
+func ConfigCommand() CommandConstructor {
+	return func() *cobra.Command {
+		return &cobra.Command{
+			Use:     "config",
+			Short:   "Print the resolved configuration",
+			Example: "  config",
+			Args:    cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				configRegistry.Lock()
+				fields := make([]configField, 0, len(configRegistry.fields))
+				for _, field := range configRegistry.fields {
+					fields = append(fields, field)
+				}
+				configRegistry.Unlock()
+
+				sort.Slice(fields, func(i, j int) bool {
+					return fields[i].key < fields[j].key
+				})
+
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "KEY\tVALUE\tDEFAULT\tSOURCE")
+				for _, field := range fields {
+					p := lookupEnv(field.key)
+					secret := field.secret || isSecretKey(field.key)
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+						field.key,
+						redact(p.value, secret),
+						redact(field.defaults, secret),
+						p.source,
+					)
+				}
+
+				return w.Flush()
+			},
+		}
+	}
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToUpper(key)
+	for _, hint := range secretHints {
+		if strings.Contains(key, hint) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func redact(value string, secret bool) string {
+	if value == "" {
+		return ""
+	}
+
+	if secret {
+		return secretMask
+	}
+
+	// only the password of URLs like DB_URL is masked
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return value
+	}
+
+	return u.Redacted()
+}
@@ -0,0 +1,57 @@
+package gema
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestConfigCommandDefaults(t *testing.T) {
+	t.Setenv("TEST_CONFIG_PORT", "9000")
+
+	Env("TEST_CONFIG_PORT").Int(8001)
+	Env("TEST_CONFIG_NAME").String("gema")
+	Env("TEST_CONFIG_TIMEOUT").Duration(time.Minute)
+	Env("TEST_CONFIG_HOSTS").Strings(",", []string{"a.com", "b.com"})
+	MustEnv("TEST_CONFIG_SECRET").String("hunter2")
+	Env("TEST_CONFIG_EMPTY").String()
+
+	var out bytes.Buffer
+	cmd := ConfigCommand().(func() *cobra.Command)()
+	cmd.SetOut(&out)
+	cmd.SetArgs(nil)
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"TEST_CONFIG_PORT":    {"9000", "8001", "env"},
+		"TEST_CONFIG_NAME":    {"gema"},
+		"TEST_CONFIG_TIMEOUT": {"1m0s"},
+		"TEST_CONFIG_HOSTS":   {"a.com,b.com"},
+		"TEST_CONFIG_SECRET":  {secretMask},
+		"TEST_CONFIG_EMPTY":   {},
+	}
+
+	rows := map[string][]string{}
+	for _, line := range strings.Split(out.String(), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			rows[fields[0]] = fields[1:]
+		}
+	}
+
+	for key, columns := range want {
+		got, ok := rows[key]
+		if !ok {
+			t.Errorf("%s is not printed:\n%s", key, out.String())
+			continue
+		}
+
+		if strings.Join(got, " ") != strings.Join(columns, " ") {
+			t.Errorf("%s = %q, want %q", key, got, columns)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package gema
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSetField(t *testing.T) {
+	var (
+		s     string
+		b     bool
+		i     int
+		i8    int8
+		u     uint16
+		f     float64
+		d     time.Duration
+		tm    time.Time
+		link  url.URL
+		plink *url.URL
+		size  ByteSize
+		ints  []int
+		pairs map[string]int
+	)
+
+	tests := []struct {
+		target  any
+		value   string
+		tag     reflect.StructTag
+		want    any
+		wantErr bool
+	}{
+		{&s, "hello", "", "hello", false},
+		{&b, "true", "", true, false},
+		{&b, "1", "", false, false},
+		{&b, "yes", "", false, false},
+		{&i, "-42", "", -42, false},
+		{&i, "4x", "", 0, true},
+		{&i8, "127", "", int8(127), false},
+		{&i8, "128", "", int8(0), true},
+		{&u, "65535", "", uint16(65535), false},
+		{&u, "-1", "", uint16(0), true},
+		{&f, "1.25", "", 1.25, false},
+		{&f, "0.1", "", 0.1, false},
+		{&f, "abc", "", 0.0, true},
+		{&d, "1m", "", time.Minute, false},
+		{&d, "60", "", time.Duration(0), true},
+		{&tm, "2024-02-29", "", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), false},
+		{&tm, "10:30", `layout:"15:04"`, time.Date(0, 1, 1, 10, 30, 0, 0, time.UTC), false},
+		{&tm, "2024-02-29", `layout:"15:04"`, time.Time{}, true},
+		{&link, "https://example.com", "", url.URL{Scheme: "https", Host: "example.com"}, false},
+		{&plink, "example.com", "", (*url.URL)(nil), true},
+		{&size, "10MB", "", ByteSize(10 << 20), false},
+		{&size, "NaN", "", ByteSize(0), true},
+		{&size, "99999999999TB", "", ByteSize(0), true},
+		{&ints, "1|2|3", `sep:"|"`, []int{1, 2, 3}, false},
+		{&ints, "1,x", "", []int(nil), true},
+		{&pairs, "a=1,b=2", "", map[string]int{"a": 1, "b": 2}, false},
+		{&pairs, "a=x", "", map[string]int(nil), true},
+	}
+
+	for _, tt := range tests {
+		val := reflect.ValueOf(tt.target).Elem()
+		val.Set(reflect.Zero(val.Type()))
+
+		err := setField(val, tt.value, tt.tag)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("setField(%s, %q) error = %v, want error %v", val.Type(), tt.value, err, tt.wantErr)
+			continue
+		}
+
+		if !reflect.DeepEqual(val.Interface(), tt.want) {
+			t.Errorf("setField(%s, %q) = %v, want %v", val.Type(), tt.value, val.Interface(), tt.want)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	type mailer struct {
+		Host string `env:"HOST" required:"true"`
+		Port int    `env:"PORT" default:"587"`
+	}
+
+	type config struct {
+		Name    string        `env:"TEST_APP_NAME" default:"gema"`
+		Debug   bool          `env:"TEST_APP_DEBUG"`
+		Timeout time.Duration `env:"TEST_APP_TIMEOUT" required:"true"`
+		Mailer  mailer        `prefix:"TEST_MAILER_"`
+	}
+
+	t.Setenv("TEST_APP_DEBUG", "yes")
+	t.Setenv("TEST_MAILER_HOST", "smtp.example.com")
+
+	var cfg config
+	err := LoadConfig(&cfg)
+
+	var envErr *EnvError
+	if !errors.As(err, &envErr) {
+		t.Fatalf("LoadConfig error = %v, want *EnvError", err)
+	}
+
+	if !errors.Is(err, ErrEnvRequired) {
+		t.Errorf("LoadConfig error = %v, want missing TEST_APP_TIMEOUT", err)
+	}
+
+	want := config{Name: "gema", Mailer: mailer{Host: "smtp.example.com", Port: 587}}
+	if cfg != want {
+		t.Errorf("LoadConfig = %+v, want %+v", cfg, want)
+	}
+
+	if err := LoadConfig(cfg); err == nil {
+		t.Error("LoadConfig of non pointer should fail")
+	}
+}
+
+func TestLoadConfigMatchesEnv(t *testing.T) {
+	type config struct {
+		Debug bool    `env:"TEST_SAME_DEBUG"`
+		Port  int     `env:"TEST_SAME_PORT"`
+		Ratio float64 `env:"TEST_SAME_RATIO"`
+	}
+
+	for _, debug := range []string{"true", "1", "yes", "TRUE"} {
+		t.Setenv("TEST_SAME_DEBUG", debug)
+		t.Setenv("TEST_SAME_PORT", "8001")
+		t.Setenv("TEST_SAME_RATIO", "0.1")
+
+		var cfg config
+		if err := LoadConfig(&cfg); err != nil {
+			t.Fatal(err)
+		}
+
+		want := config{
+			Debug: Env("TEST_SAME_DEBUG").Bool(),
+			Port:  Env("TEST_SAME_PORT").Int(),
+			Ratio: Env("TEST_SAME_RATIO").Float64(),
+		}
+
+		if cfg != want {
+			t.Errorf("LoadConfig with TEST_SAME_DEBUG=%s = %+v, Env = %+v", debug, cfg, want)
+		}
+	}
+}
@@ -59,8 +59,13 @@ func (t *DB) Tx(ctx context.Context) bun.IDB {
 }
 
 // DatabaseModule connect the database using bun with pgxpool and provides the bun.DB instance.
+// It also provides the "database" health checker that pings the pool
 func DatabaseModule(dbUrl string) fx.Option {
 	return fx.Module("database", fx.Provide(
+		AsHealthChecker(func(pool *pgxpool.Pool) HealthChecker {
+			return NewHealthChecker("database", pool.Ping)
+		}),
+
 		func(lc fx.Lifecycle) (*pgxpool.Pool, *sql.DB, *DB) {
 			pool, err := pgxpool.New(context.Background(), dbUrl)
 			if err != nil {
@@ -0,0 +1,291 @@
+package gema
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// envOrigins keeps track of the file each environment variable is loaded from
+var envOrigins = struct {
+	sync.RWMutex
+	files map[string]string
+
+	// dir and appEnv of the last LoadDotenv call, used to reload the files
+	dir    string
+	appEnv string
+	loaded bool
+}{files: map[string]string{}}
+
+func envOrigin(key string) (string, bool) {
+	envOrigins.RLock()
+	defer envOrigins.RUnlock()
+
+	file, ok := envOrigins.files[key]
+	return file, ok
+}
+
+// dotenvFiles returns the dotenv files from the lowest to the highest precedence
+func dotenvFiles(appEnv string) []string {
+	files := []string{".env", ".env.local"}
+	if appEnv != "" {
+		files = append(files, ".env."+appEnv, ".env."+appEnv+".local")
+	}
+
+	return files
+}
+
+type dotenvValue struct {
+	value string
+	file  string
+
+	// literal values are single quoted and will not be interpolated
+	literal bool
+}
+
+// LoadDotenv loads the dotenv files inside dir (defaults to the working directory) into the process
+// environment. The files are loaded with the following precedence, from the highest to the lowest:
+//
+//   - the process environment
+//   - .env.{APP_ENV}.local
+//   - .env.{APP_ENV}
+//   - .env.local
+//   - .env
+//
+// APP_ENV is read from the process environment, or from .env and .env.local otherwise.
+// Values may reference other variables with ${OTHER_VAR}, and double or single quoted values
+// may span multiple lines. Single quoted values are taken literally.
+// The file each value is loaded from is reported by Parser.Source
+func LoadDotenv(dir ...string) error {
+	root := "."
+	if len(dir) > 0 {
+		root = dir[0]
+	}
+
+	envOrigins.Lock()
+	defer envOrigins.Unlock()
+
+	return loadDotenv(root)
+}
+
+// reloadDotenv re-reads the files of the last LoadDotenv call. Values that were loaded
+// from the files are updated or unset, while the process environment still takes precedence
+func reloadDotenv() error {
+	envOrigins.Lock()
+	defer envOrigins.Unlock()
+
+	if !envOrigins.loaded {
+		return nil
+	}
+
+	return loadDotenv(envOrigins.dir)
+}
+
+// dotenvWatchFiles returns the dotenv files of the last LoadDotenv call, including the missing ones
+func dotenvWatchFiles() []string {
+	envOrigins.RLock()
+	defer envOrigins.RUnlock()
+
+	if !envOrigins.loaded {
+		return nil
+	}
+
+	var files []string
+	for _, name := range dotenvFiles(envOrigins.appEnv) {
+		files = append(files, filepath.Join(envOrigins.dir, name))
+	}
+
+	return files
+}
+
+// loadDotenv must be called while holding the envOrigins lock
+func loadDotenv(root string) error {
+	values := map[string]dotenvValue{}
+	load := func(name string) error {
+		path := filepath.Join(root, name)
+		content, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		parsed, err := parseDotenv(string(content), path)
+		if err != nil {
+			return err
+		}
+
+		for key, val := range parsed {
+			values[key] = val
+		}
+
+		return nil
+	}
+
+	// .env and .env.local may define APP_ENV, so they are loaded first
+	base := dotenvFiles("")
+	for _, name := range base {
+		if err := load(name); err != nil {
+			return err
+		}
+	}
+
+	appEnv, exists := os.LookupEnv("APP_ENV")
+	if _, owned := envOrigins.files["APP_ENV"]; !exists || owned {
+		appEnv = values["APP_ENV"].value
+	}
+
+	for _, name := range dotenvFiles(appEnv)[len(base):] {
+		if err := load(name); err != nil {
+			return err
+		}
+	}
+
+	for key, val := range values {
+		_, exists := os.LookupEnv(key)
+		if _, owned := envOrigins.files[key]; exists && !owned {
+			continue
+		}
+
+		if err := os.Setenv(key, interpolate(key, values, map[string]bool{})); err != nil {
+			return err
+		}
+
+		envOrigins.files[key] = val.file
+	}
+
+	// the values removed from the files are no longer set
+	for key := range envOrigins.files {
+		if _, ok := values[key]; !ok {
+			os.Unsetenv(key)
+			delete(envOrigins.files, key)
+		}
+	}
+
+	envOrigins.dir = root
+	envOrigins.appEnv = appEnv
+	envOrigins.loaded = true
+	return nil
+}
+
+// interpolate expands ${VAR} and $VAR inside the value of key. The process environment
+// takes precedence over the dotenv values, and circular references expand to empty string.
+// It must be called while holding the envOrigins lock
+func interpolate(key string, values map[string]dotenvValue, visiting map[string]bool) string {
+	val := values[key]
+	if val.literal {
+		return val.value
+	}
+
+	visiting[key] = true
+	defer delete(visiting, key)
+
+	return os.Expand(val.value, func(name string) string {
+		// values previously loaded from the files are resolved again from the files
+		if _, owned := envOrigins.files[name]; !owned {
+			if env, ok := os.LookupEnv(name); ok {
+				return env
+			}
+		}
+
+		if _, ok := values[name]; !ok || visiting[name] {
+			return ""
+		}
+
+		return interpolate(name, values, visiting)
+	})
+}
+
+// parseDotenv parses the content of a dotenv file. The file is only used to report the origin
+func parseDotenv(content, file string) (map[string]dotenvValue, error) {
+	values := map[string]dotenvValue{}
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	line := 1
+	for len(content) > 0 {
+		var current string
+		current, content, _ = strings.Cut(content, "\n")
+		start := line
+		line++
+
+		current = strings.TrimSpace(current)
+		if current == "" || strings.HasPrefix(current, "#") {
+			continue
+		}
+
+		current = strings.TrimPrefix(current, "export ")
+		key, raw, ok := strings.Cut(current, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("[Gema] %s:%d: invalid line %q", file, start, current)
+		}
+
+		raw = strings.TrimSpace(raw)
+		val := dotenvValue{file: file}
+
+		if raw != "" && (raw[0] == '"' || raw[0] == '\'') {
+			quote := raw[0]
+			body := raw[1:]
+
+			// multi-line value: keep consuming lines until the closing quote
+			end := closingQuote(body, quote)
+			for end < 0 && len(content) > 0 {
+				var next string
+				next, content, _ = strings.Cut(content, "\n")
+				line++
+				body += "\n" + next
+				end = closingQuote(body, quote)
+			}
+
+			if end < 0 {
+				return nil, fmt.Errorf("[Gema] %s:%d: unterminated quoted value of %s", file, start, key)
+			}
+
+			val.value = body[:end]
+			if quote == '"' {
+				val.value = unescape(val.value)
+			} else {
+				val.literal = true
+			}
+		} else {
+			// strip the inline comment of unquoted value
+			if i := strings.Index(raw, " #"); i >= 0 {
+				raw = raw[:i]
+			}
+
+			val.value = strings.TrimSpace(raw)
+		}
+
+		values[key] = val
+	}
+
+	return values, nil
+}
+
+// closingQuote returns the index of the unescaped closing quote, or -1 if there is none
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && quote == '"' {
+			i++
+			continue
+		}
+
+		if s[i] == quote {
+			return i
+		}
+	}
+
+	return -1
+}
+
+var dotenvEscapes = strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+
+func unescape(s string) string {
+	return dotenvEscapes.Replace(s)
+}
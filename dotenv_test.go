@@ -0,0 +1,183 @@
+package gema
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	content := strings.Join([]string{
+		"# comment",
+		"",
+		"PLAIN=value",
+		"SPACED = spaced value ",
+		"export EXPORTED=yes",
+		"EMPTY=",
+		"INLINE=value # comment",
+		"HASH=a#b",
+		`DOUBLE="quoted # not a comment"`,
+		`ESCAPED="line\nnext\t\"quoted\" \\"`,
+		`SINGLE='literal ${PLAIN} \n'`,
+		`MULTI="first`,
+		`second"`,
+		"CRLF=value\r",
+	}, "\n")
+
+	values, err := parseDotenv(content, ".env")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]dotenvValue{
+		"PLAIN":    {value: "value"},
+		"SPACED":   {value: "spaced value"},
+		"EXPORTED": {value: "yes"},
+		"EMPTY":    {value: ""},
+		"INLINE":   {value: "value"},
+		"HASH":     {value: "a#b"},
+		"DOUBLE":   {value: "quoted # not a comment"},
+		"ESCAPED":  {value: "line\nnext\t\"quoted\" \\"},
+		"SINGLE":   {value: `literal ${PLAIN} \n`, literal: true},
+		"MULTI":    {value: "first\nsecond"},
+		"CRLF":     {value: "value"},
+	}
+
+	if len(values) != len(want) {
+		t.Errorf("parseDotenv returned %d values, want %d: %+v", len(values), len(want), values)
+	}
+
+	for key, w := range want {
+		got, ok := values[key]
+		if !ok {
+			t.Errorf("%s is missing", key)
+			continue
+		}
+
+		if got.value != w.value || got.literal != w.literal || got.file != ".env" {
+			t.Errorf("%s = %+v, want %+v", key, got, w)
+		}
+	}
+}
+
+func TestParseDotenvErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"NOVALUE", ".env:1: invalid line"},
+		{"A=1\n=value", ".env:2: invalid line"},
+		{"BAD KEY=value", ".env:1: invalid line"},
+		{"A=1\nQUOTED=\"never closed\nB=2", ".env:2: unterminated quoted value of QUOTED"},
+		{`ESCAPED="ends with \"`, ".env:1: unterminated quoted value of ESCAPED"},
+	}
+
+	for _, tt := range tests {
+		_, err := parseDotenv(tt.content, ".env")
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseDotenv(%q) error = %v, want %q", tt.content, err, tt.want)
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("TEST_DOTENV_PROCESS", "process")
+
+	values := map[string]dotenvValue{
+		"HOST":       {value: "localhost"},
+		"PORT":       {value: "5432"},
+		"URL":        {value: "postgres://${HOST}:$PORT/db"},
+		"NESTED":     {value: "${URL}?sslmode=disable"},
+		"LITERAL":    {value: "${HOST}", literal: true},
+		"PROCESS":    {value: "${TEST_DOTENV_PROCESS}"},
+		"MISSING":    {value: "[${TEST_DOTENV_MISSING}]"},
+		"SELF":       {value: "a${SELF}b"},
+		"CYCLE_A":    {value: "a${CYCLE_B}"},
+		"CYCLE_B":    {value: "b${CYCLE_A}"},
+		"TO_LITERAL": {value: "${LITERAL}!"},
+	}
+
+	tests := map[string]string{
+		"URL":        "postgres://localhost:5432/db",
+		"NESTED":     "postgres://localhost:5432/db?sslmode=disable",
+		"LITERAL":    "${HOST}",
+		"PROCESS":    "process",
+		"MISSING":    "[]",
+		"SELF":       "ab",
+		"CYCLE_A":    "ab",
+		"CYCLE_B":    "ba",
+		"TO_LITERAL": "${HOST}!",
+	}
+
+	envOrigins.Lock()
+	defer envOrigins.Unlock()
+
+	for key, want := range tests {
+		if got := interpolate(key, values, map[string]bool{}); got != want {
+			t.Errorf("interpolate(%s) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestLoadDotenv(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".env":            "TEST_DOTENV_A=env\nTEST_DOTENV_B=env\nTEST_DOTENV_C=env\nAPP_ENV=test\n",
+		".env.local":      "TEST_DOTENV_B=local\n",
+		".env.test":       "TEST_DOTENV_C=test\nTEST_DOTENV_REF=${TEST_DOTENV_B}-${TEST_DOTENV_C}\n",
+		".env.test.local": "TEST_DOTENV_PROCESS=file\n",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv("TEST_DOTENV_PROCESS", "process")
+	for _, key := range []string{"APP_ENV", "TEST_DOTENV_A", "TEST_DOTENV_B", "TEST_DOTENV_C", "TEST_DOTENV_REF"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+
+	if err := LoadDotenv(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key    string
+		value  string
+		source string
+	}{
+		{"TEST_DOTENV_A", "env", ".env"},
+		{"TEST_DOTENV_B", "local", ".env.local"},
+		{"TEST_DOTENV_C", "test", ".env.test"},
+		{"TEST_DOTENV_REF", "local-test", ".env.test"},
+		{"TEST_DOTENV_PROCESS", "process", "env"},
+	}
+
+	for _, tt := range tests {
+		p := Env(tt.key)
+		if p.String() != tt.value || filepath.Base(p.Source()) != tt.source {
+			t.Errorf("%s = %q from %q, want %q from %q", tt.key, p.String(), p.Source(), tt.value, tt.source)
+		}
+	}
+
+	// the values removed from the files are unset on reload
+	if err := os.WriteFile(filepath.Join(dir, ".env.test"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reloadDotenv(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := Env("TEST_DOTENV_C").String(); got != "env" {
+		t.Errorf("TEST_DOTENV_C after reload = %q, want %q", got, "env")
+	}
+
+	if _, ok := os.LookupEnv("TEST_DOTENV_REF"); ok {
+		t.Error("TEST_DOTENV_REF should be unset after reload")
+	}
+}
@@ -1,7 +1,47 @@
 package gema
 
-import "os"
+import "sync"
 
+// configRegistry keeps every configuration key resolved through Env or LoadConfig,
+// so they can be printed by ConfigCommand
+var configRegistry = struct {
+	sync.Mutex
+	fields map[string]configField
+}{fields: map[string]configField{}}
+
+func registerConfig(field configField) {
+	configRegistry.Lock()
+	defer configRegistry.Unlock()
+
+	// keep the default and the secret flag declared by LoadConfig
+	if existing, ok := configRegistry.fields[field.key]; ok {
+		if field.defaults == "" {
+			field.defaults = existing.defaults
+		}
+
+		field.secret = existing.secret || field.secret
+	}
+
+	configRegistry.fields[field.key] = field
+}
+
+// Env resolves the value of key from the registered config sources. See SetConfigSources
 func Env(key string) Parser {
-	return parseString(os.Getenv(key))
+	registerConfig(configField{key: key})
+	return lookupEnv(key)
+}
+
+// MustEnv is like Env, but the returned parser panics with the variable name
+// and its raw value when the value can not be parsed, so misconfiguration fails at startup
+func MustEnv(key string) Parser {
+	registerConfig(configField{key: key})
+
+	p := lookupEnv(key)
+	p.must = true
+	return p
+}
+
+func lookupEnv(key string) *parser {
+	value, source, _ := lookupSources(key)
+	return &parser{key: key, value: value, source: source}
 }
@@ -0,0 +1,275 @@
+package gema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// OrderGrpcError is the order of the gRPC error interceptor provided by GrpcServerModule.
+// It is the outermost one, so the logger still logs the cause hidden from the clients
+const OrderGrpcError = -300
+
+// ErrorCode is the transport neutral code of Error
+type ErrorCode string
+
+const (
+	CodeInvalidArgument    ErrorCode = "INVALID_ARGUMENT"
+	CodeUnauthenticated    ErrorCode = "UNAUTHENTICATED"
+	CodePermissionDenied   ErrorCode = "PERMISSION_DENIED"
+	CodeNotFound           ErrorCode = "NOT_FOUND"
+	CodeConflict           ErrorCode = "CONFLICT"
+	CodeFailedPrecondition ErrorCode = "FAILED_PRECONDITION"
+	CodeTooManyRequests    ErrorCode = "TOO_MANY_REQUESTS"
+	CodeCanceled           ErrorCode = "CANCELED"
+	CodeDeadlineExceeded   ErrorCode = "DEADLINE_EXCEEDED"
+	CodeUnimplemented      ErrorCode = "UNIMPLEMENTED"
+	CodeUnavailable        ErrorCode = "UNAVAILABLE"
+	CodeInternal           ErrorCode = "INTERNAL"
+)
+
+type errorMapping struct {
+	http int
+	grpc codes.Code
+}
+
+var errorMappings = map[ErrorCode]errorMapping{
+	CodeInvalidArgument:    {http.StatusBadRequest, codes.InvalidArgument},
+	CodeUnauthenticated:    {http.StatusUnauthorized, codes.Unauthenticated},
+	CodePermissionDenied:   {http.StatusForbidden, codes.PermissionDenied},
+	CodeNotFound:           {http.StatusNotFound, codes.NotFound},
+	CodeConflict:           {http.StatusConflict, codes.AlreadyExists},
+	CodeFailedPrecondition: {http.StatusUnprocessableEntity, codes.FailedPrecondition},
+	CodeTooManyRequests:    {http.StatusTooManyRequests, codes.ResourceExhausted},
+	CodeCanceled:           {499, codes.Canceled},
+	CodeDeadlineExceeded:   {http.StatusGatewayTimeout, codes.DeadlineExceeded},
+	CodeUnimplemented:      {http.StatusNotImplemented, codes.Unimplemented},
+	CodeUnavailable:        {http.StatusServiceUnavailable, codes.Unavailable},
+	CodeInternal:           {http.StatusInternalServerError, codes.Internal},
+}
+
+// Error is the application error returned by the services regardless of the transport. It is mapped
+// to the http response by the error handler of StartHTTP, and to the gRPC status by GrpcServerModule.
+// The wrapped Err is only exposed to the clients when APP_ENV is "development"
+type Error struct {
+	Code    ErrorCode
+	Message string
+	Details map[string]any
+	Err     error
+}
+
+// NewError creates an Error with the code and the message shown to the clients
+func NewError(code ErrorCode, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// WrapError creates an Error caused by err
+func WrapError(err error, code ErrorCode, message string) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+// WithDetail adds the detail shown to the clients, e.g. the conflicting field
+func (e *Error) WithDetail(key string, value any) *Error {
+	if e.Details == nil {
+		e.Details = map[string]any{}
+	}
+
+	e.Details[key] = value
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) mapping() errorMapping {
+	if m, ok := errorMappings[e.Code]; ok {
+		return m
+	}
+
+	return errorMappings[CodeInternal]
+}
+
+// HTTPStatus returns the http status code of the error
+func (e *Error) HTTPStatus() int {
+	return e.mapping().http
+}
+
+// GRPCStatus returns the gRPC status of the error, with the code and the details as google.rpc.ErrorInfo.
+// It allows status.FromError to convert the error
+func (e *Error) GRPCStatus() *status.Status {
+	st := status.New(e.mapping().grpc, e.Message)
+
+	info := &errdetails.ErrorInfo{Reason: string(e.Code), Metadata: map[string]string{}}
+	for key, value := range e.Details {
+		info.Metadata[key] = fmt.Sprint(value)
+	}
+
+	details := []protoadapt.MessageV1{info}
+	if e.Err != nil && isDevelopment() {
+		details = append(details, &errdetails.DebugInfo{Detail: e.Err.Error()})
+	}
+
+	if detailed, err := st.WithDetails(details...); err == nil {
+		st = detailed
+	}
+
+	return st
+}
+
+// isDevelopment reads APP_ENV through Env, so it may come from any of the config sources
+func isDevelopment() bool {
+	return Env("APP_ENV").String() == "development"
+}
+
+// grpcCodeAliases maps the gRPC codes without their own ErrorCode
+var grpcCodeAliases = map[codes.Code]ErrorCode{
+	codes.OutOfRange: CodeInvalidArgument,
+	codes.Aborted:    CodeConflict,
+}
+
+// errorFromStatus converts the gRPC status back to Error, e.g. the error returned through the gateway
+func errorFromStatus(st *status.Status) *Error {
+	e := &Error{Code: CodeInternal, Message: st.Message()}
+	if code, ok := grpcCodeAliases[st.Code()]; ok {
+		e.Code = code
+	}
+
+	for code, m := range errorMappings {
+		if m.grpc == st.Code() {
+			e.Code = code
+		}
+	}
+
+	for _, detail := range st.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			if _, ok := errorMappings[ErrorCode(detail.Reason)]; ok {
+				e.Code = ErrorCode(detail.Reason)
+			}
+
+			for key, value := range detail.Metadata {
+				e.WithDetail(key, value)
+			}
+		case *errdetails.BadRequest:
+			for _, violation := range detail.FieldViolations {
+				e.WithDetail(violation.Field, violation.Description)
+			}
+		case *errdetails.DebugInfo:
+			e.Err = errors.New(detail.Detail)
+		}
+	}
+
+	return e
+}
+
+type errorResponse struct {
+	Code    ErrorCode      `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+	Cause   string         `json:"cause,omitempty"`
+}
+
+// registerErrorHandler renders Error as JSON, other errors are handled by the error handler
+// set by the application, or the default error handler of echo
+func registerErrorHandler(e *echo.Echo) {
+	previous := e.HTTPErrorHandler
+	if previous == nil {
+		previous = e.DefaultHTTPErrorHandler
+	}
+
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		var appErr *Error
+		if !errors.As(err, &appErr) {
+			previous(err, c)
+			return
+		}
+
+		if c.Response().Committed {
+			return
+		}
+
+		res := errorResponse{
+			Code:    appErr.Code,
+			Message: appErr.Message,
+			Details: appErr.Details,
+		}
+
+		if appErr.Err != nil && isDevelopment() {
+			res.Cause = appErr.Err.Error()
+		}
+
+		if c.Request().Method == http.MethodHead {
+			err = c.NoContent(appErr.HTTPStatus())
+		} else {
+			err = c.JSON(appErr.HTTPStatus(), res)
+		}
+
+		if err != nil {
+			e.Logger.Error(err)
+		}
+	}
+}
+
+// grpcError converts the error returned by the handler to the gRPC status. The errors other than Error
+// and the gRPC status are converted to codes.Internal, with the message hidden outside development
+func grpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.GRPCStatus().Err()
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case isDevelopment():
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	return status.Error(codes.Internal, http.StatusText(http.StatusInternalServerError))
+}
+
+type grpcErrorMapper struct{}
+
+func newGrpcErrorMapper() *grpcErrorMapper {
+	return &grpcErrorMapper{}
+}
+
+func (m *grpcErrorMapper) Order() int {
+	return OrderGrpcError
+}
+
+func (m *grpcErrorMapper) InterceptUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+	return resp, grpcError(err)
+}
+
+func (m *grpcErrorMapper) InterceptStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return grpcError(handler(srv, ss))
+}
@@ -0,0 +1,69 @@
+package gema
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+func TestErrorHandlerKeepsPrevious(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		c.String(http.StatusTeapot, "custom: "+err.Error())
+	}
+
+	registerErrorHandler(e)
+	e.GET("/app", func(c echo.Context) error {
+		return NewError(CodeNotFound, "user not found")
+	})
+	e.GET("/other", func(c echo.Context) error {
+		return errors.New("boom")
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/app", http.StatusNotFound, `{"code":"NOT_FOUND","message":"user not found"}` + "\n"},
+		{"/other", http.StatusTeapot, "custom: boom"},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.status || rec.Body.String() != tt.body {
+			t.Errorf("GET %s = %d %q, want %d %q", tt.path, rec.Code, rec.Body.String(), tt.status, tt.body)
+		}
+	}
+}
+
+func TestErrorCauseFromConfigSource(t *testing.T) {
+	t.Setenv("APP_ENV", "")
+	os.Unsetenv("APP_ENV")
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"app": {"env": "development"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetConfigSources(ProcessEnvSource(), JSONFileSource(path)); err != nil {
+		t.Fatal(err)
+	}
+	defer SetConfigSources(ProcessEnvSource(), SecretFileSource())
+
+	st := WrapError(errors.New("connection refused"), CodeUnavailable, "try again").GRPCStatus()
+	for _, detail := range st.Details() {
+		if debug, ok := detail.(*errdetails.DebugInfo); ok && debug.Detail == "connection refused" {
+			return
+		}
+	}
+
+	t.Errorf("the cause is hidden with APP_ENV from the config file: %v", st.Details())
+}
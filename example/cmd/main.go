@@ -5,7 +5,6 @@ import (
 	"context"
 	"embed"
 
-	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
 	"github.com/thoriqadillah/gema"
 	"go.uber.org/fx"
@@ -25,7 +24,10 @@ func helloWorld() *cobra.Command {
 }
 
 func main() {
-	godotenv.Load()
+	if err := gema.LoadDotenv(); err != nil {
+		panic(err)
+	}
+
 	env.Load()
 	ctx := context.Background()
 
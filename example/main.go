@@ -7,7 +7,6 @@ import (
 	"fmt"
 
 	"github.com/go-playground/validator/v10"
-	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/thoriqadillah/gema"
@@ -19,11 +18,15 @@ import (
 var templateFs embed.FS
 
 func httpServer() *echo.Echo {
-	e := echo.New()
-	e.Use(middleware.Gzip())
-	e.Use(middleware.Recover())
+	return echo.New()
+}
+
+func recoverMiddleware() gema.Middleware {
+	return gema.MiddlewareFunc(gema.OrderHTTPLogger+1, middleware.Recover())
+}
 
-	return e
+func gzipMiddleware() gema.Middleware {
+	return gema.MiddlewareFunc(0, middleware.Gzip())
 }
 
 func grpcServer() *grpc.Server {
@@ -56,7 +59,10 @@ func registerValidation() {
 }
 
 func init() {
-	godotenv.Load()
+	if err := gema.LoadDotenv(); err != nil {
+		panic(err)
+	}
+
 	env.Load()
 }
 
@@ -67,6 +73,10 @@ func main() {
 		gema.FxLogger,
 		gema.LoggerModule(env.APP_ENV),
 		fx.Provide(httpServer),
+		fx.Provide(
+			gema.AsMiddleware(recoverMiddleware),
+			gema.AsMiddleware(gzipMiddleware),
+		),
 		fx.Provide(grpcServer),
 		gema.DatabaseModule(env.DB_URL),
 		gema.NotifierModule(gema.EmailerProvider(emailConfig())),
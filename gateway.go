@@ -0,0 +1,566 @@
+package gema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+const gatewayBufferSize = 1 << 20
+
+// GatewayRoute maps an http route to a unary gRPC method, e.g.
+//
+//	gema.GatewayRoute{Method: "POST", Path: "/users", GrpcMethod: "/user.v1.UserService/CreateUser", Body: "*"}
+//
+// Body is the request field the http body is decoded into, "*" for the whole request message,
+// or empty if the route has no body. The path parameters and the query parameters
+// are set to the request fields of the same name, nested fields are separated by dot
+type GatewayRoute struct {
+	Method     string
+	Path       string
+	GrpcMethod string
+	Body       string
+}
+
+// GatewayService is an optional interface of the GrpcService to declare its http routes,
+// in addition to the google.api.http annotations of its methods
+type GatewayService interface {
+	GatewayRoutes() []GatewayRoute
+}
+
+type gatewayParams struct {
+	fx.In
+
+	fx.Lifecycle
+	*echo.Echo
+	*grpc.Server
+	Registered *grpcServices `optional:"true"`
+	Services   []GrpcService `group:"grpc_services"`
+}
+
+// gatewayRoute is the GatewayRoute read from the google.api.http annotation, with the wildcard
+// of the path template variable spanning multiple segments, e.g. {name=shelves/*}
+type gatewayRoute struct {
+	GatewayRoute
+	wildcard *pathWildcard
+}
+
+// pathWildcard binds the echo wildcard parameter to the field. The literal prefix of the segment pattern
+// is part of the echo path, so it is prepended to the captured value, which must match the pattern
+type pathWildcard struct {
+	field   string
+	prefix  string
+	pattern *regexp.Regexp
+}
+
+type gatewayMethod struct {
+	gatewayRoute
+	input  protoreflect.MessageType
+	output protoreflect.MessageType
+}
+
+// StartGateway exposes the methods of the services registered with AsGrpcService as JSON endpoints
+// on the echo instance of StartHTTP. The routes are read from the google.api.http annotations
+// and the GatewayService. The requests go through the in-process *grpc.Server, so both
+// the echo middleware and the gRPC interceptors apply, and the gRPC status is mapped to the http status
+func StartGateway() fx.Option {
+	return fx.Module("start_gateway",
+		fx.Invoke(func(p gatewayParams) error {
+			registerGrpcServices(p.Registered, p.Server, p.Services)
+
+			methods, err := gatewayMethods(p.Server, p.Services)
+			if err != nil {
+				return err
+			}
+
+			listener := bufconn.Listen(gatewayBufferSize)
+			conn, err := grpc.NewClient("passthrough:///gateway",
+				grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+					return listener.DialContext(ctx)
+				}),
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+			)
+
+			if err != nil {
+				return err
+			}
+
+			for _, method := range methods {
+				p.Add(method.Method, method.Path, gatewayHandler(conn, method))
+			}
+
+			p.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					// the error is ignored, it is always returned once the listener is closed
+					go p.Server.Serve(listener)
+					return nil
+				},
+				OnStop: func(ctx context.Context) error {
+					conn.Close()
+					return listener.Close()
+				},
+			})
+
+			return nil
+		}),
+	)
+}
+
+func gatewayMethods(server *grpc.Server, services []GrpcService) ([]gatewayMethod, error) {
+	var routes []gatewayRoute
+	for name := range server.GetServiceInfo() {
+		desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			continue
+		}
+
+		service, ok := desc.(protoreflect.ServiceDescriptor)
+		if !ok {
+			continue
+		}
+
+		for i := 0; i < service.Methods().Len(); i++ {
+			method := service.Methods().Get(i)
+			rule, ok := proto.GetExtension(method.Options(), annotations.E_Http).(*annotations.HttpRule)
+			if !ok || rule == nil {
+				continue
+			}
+
+			grpcMethod := fmt.Sprintf("/%s/%s", service.FullName(), method.Name())
+			for _, binding := range append([]*annotations.HttpRule{rule}, rule.AdditionalBindings...) {
+				route, ok, err := routeFromRule(binding, grpcMethod)
+				if err != nil {
+					return nil, err
+				}
+
+				if ok {
+					routes = append(routes, route)
+				}
+			}
+		}
+	}
+
+	for _, service := range services {
+		if gateway, ok := service.(GatewayService); ok {
+			for _, route := range gateway.GatewayRoutes() {
+				routes = append(routes, gatewayRoute{GatewayRoute: route})
+			}
+		}
+	}
+
+	methods := make([]gatewayMethod, 0, len(routes))
+	for _, route := range routes {
+		method, err := resolveGatewayMethod(route)
+		if err != nil {
+			return nil, err
+		}
+
+		methods = append(methods, method)
+	}
+
+	return methods, nil
+}
+
+var pathTemplateVar = regexp.MustCompile(`\{([^}=]+)(?:=([^}]*))?\}`)
+
+func routeFromRule(rule *annotations.HttpRule, grpcMethod string) (gatewayRoute, bool, error) {
+	var method, path string
+	switch pattern := rule.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		method, path = http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Post:
+		method, path = http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Put:
+		method, path = http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Patch:
+		method, path = http.MethodPatch, pattern.Patch
+	case *annotations.HttpRule_Delete:
+		method, path = http.MethodDelete, pattern.Delete
+	case *annotations.HttpRule_Custom:
+		method, path = pattern.Custom.Kind, pattern.Custom.Path
+	default:
+		return gatewayRoute{}, false, nil
+	}
+
+	echoPath, wildcard, err := echoPathOf(path)
+	if err != nil {
+		return gatewayRoute{}, false, fmt.Errorf("[Gema] Path template %q of %s is not supported: %w", path, grpcMethod, err)
+	}
+
+	return gatewayRoute{
+		GatewayRoute: GatewayRoute{
+			Method:     method,
+			Path:       echoPath,
+			GrpcMethod: grpcMethod,
+			Body:       rule.Body,
+		},
+		wildcard: wildcard,
+	}, true, nil
+}
+
+// echoPathOf converts the path template variables to the echo path parameters, e.g. /users/{id} to /users/:id.
+// A variable spanning multiple segments, e.g. {name=shelves/*} or {name=**}, is converted to the echo wildcard,
+// so it must be the last part of the path
+func echoPathOf(template string) (string, *pathWildcard, error) {
+	var (
+		path     strings.Builder
+		wildcard *pathWildcard
+		last     int
+	)
+
+	for _, match := range pathTemplateVar.FindAllStringSubmatchIndex(template, -1) {
+		path.WriteString(template[last:match[0]])
+		last = match[1]
+
+		field := template[match[2]:match[3]]
+		pattern := "*"
+		if match[4] >= 0 {
+			pattern = template[match[4]:match[5]]
+		}
+
+		if pattern == "*" {
+			path.WriteString(":" + field)
+			continue
+		}
+
+		if match[1] != len(template) {
+			return "", nil, errors.New("the multi segment variable must be the last part of the path")
+		}
+
+		// the leading literal segments stay in the echo path, e.g. {name=shelves/*} is routed as shelves/*
+		var prefix string
+		var expr []string
+		literal := true
+		segments := strings.Split(pattern, "/")
+		for i, segment := range segments {
+			switch {
+			case segment == "**" && i == len(segments)-1:
+				expr = append(expr, ".*")
+				literal = false
+			case segment == "*":
+				expr = append(expr, "[^/]+")
+				literal = false
+			case segment == "" || strings.Contains(segment, "*"):
+				return "", nil, fmt.Errorf("invalid segment %q", segment)
+			default:
+				expr = append(expr, regexp.QuoteMeta(segment))
+				if literal {
+					prefix += segment + "/"
+				}
+			}
+		}
+
+		if literal {
+			return "", nil, fmt.Errorf("pattern %q has no wildcard", pattern)
+		}
+
+		wildcard = &pathWildcard{
+			field:   field,
+			prefix:  prefix,
+			pattern: regexp.MustCompile("^" + strings.Join(expr, "/") + "$"),
+		}
+
+		path.WriteString(prefix + "*")
+	}
+
+	path.WriteString(template[last:])
+	return path.String(), wildcard, nil
+}
+
+func resolveGatewayMethod(route gatewayRoute) (gatewayMethod, error) {
+	serviceName, methodName, ok := strings.Cut(strings.TrimPrefix(route.GrpcMethod, "/"), "/")
+	if !ok {
+		return gatewayMethod{}, fmt.Errorf("[Gema] Invalid gRPC method %q, expected /package.Service/Method", route.GrpcMethod)
+	}
+
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(serviceName))
+	if err != nil {
+		return gatewayMethod{}, fmt.Errorf("[Gema] gRPC service of %s is not found: %w", route.GrpcMethod, err)
+	}
+
+	service, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return gatewayMethod{}, fmt.Errorf("[Gema] %s is not a gRPC service", serviceName)
+	}
+
+	method := service.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		return gatewayMethod{}, fmt.Errorf("[Gema] gRPC method %s is not found", route.GrpcMethod)
+	}
+
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return gatewayMethod{}, fmt.Errorf("[Gema] Streaming gRPC method %s can't be exposed on the gateway", route.GrpcMethod)
+	}
+
+	input, err := protoregistry.GlobalTypes.FindMessageByName(method.Input().FullName())
+	if err != nil {
+		return gatewayMethod{}, err
+	}
+
+	output, err := protoregistry.GlobalTypes.FindMessageByName(method.Output().FullName())
+	if err != nil {
+		return gatewayMethod{}, err
+	}
+
+	return gatewayMethod{route, input, output}, nil
+}
+
+func gatewayHandler(conn *grpc.ClientConn, method gatewayMethod) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := method.input.New()
+		if err := decodeGatewayBody(c.Request(), req, method.Body); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		for _, name := range c.ParamNames() {
+			if name == "*" {
+				continue
+			}
+
+			if err := setMessageField(req, name, []string{c.Param(name)}); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+		}
+
+		if w := method.wildcard; w != nil {
+			value := w.prefix + c.Param("*")
+			if !w.pattern.MatchString(value) {
+				return echo.ErrNotFound
+			}
+
+			if err := setMessageField(req, w.field, []string{value}); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+		}
+
+		// the query parameters are not bound if the whole message is read from the body
+		if method.Body != "*" {
+			for name, values := range c.QueryParams() {
+				if err := setMessageField(req, name, values); err != nil && !errors.Is(err, errUnknownField) {
+					return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+				}
+			}
+		}
+
+		ctx := metadata.NewOutgoingContext(c.Request().Context(), gatewayMetadata(c.Request()))
+		res := method.output.New().Interface()
+		if err := conn.Invoke(ctx, method.GrpcMethod, req.Interface(), res); err != nil {
+			return errorFromStatus(status.Convert(err))
+		}
+
+		body, err := protojson.Marshal(res)
+		if err != nil {
+			return err
+		}
+
+		return c.JSONBlob(http.StatusOK, body)
+	}
+}
+
+func decodeGatewayBody(r *http.Request, req protoreflect.Message, field string) error {
+	if field == "" {
+		return nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil || len(body) == 0 {
+		return err
+	}
+
+	target := req
+	if field != "*" {
+		fd := req.Descriptor().Fields().ByName(protoreflect.Name(field))
+		if fd == nil || fd.Message() == nil || fd.IsList() || fd.IsMap() {
+			return fmt.Errorf("body field %s must be a message field", field)
+		}
+
+		target = req.Mutable(fd).Message()
+	}
+
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(body, target.Interface())
+}
+
+var errUnknownField = errors.New("unknown field")
+
+// setMessageField sets the field of the path, e.g. address.city, to the values.
+// The fields are matched by their proto name or their JSON name
+func setMessageField(msg protoreflect.Message, path string, values []string) error {
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		fields := msg.Descriptor().Fields()
+		fd := fields.ByName(protoreflect.Name(part))
+		if fd == nil {
+			fd = fields.ByJSONName(part)
+		}
+
+		if fd == nil {
+			return fmt.Errorf("%w %s", errUnknownField, path)
+		}
+
+		if i < len(parts)-1 {
+			if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+				return fmt.Errorf("%s is not a message field", path)
+			}
+
+			msg = msg.Mutable(fd).Message()
+			continue
+		}
+
+		if fd.IsMap() {
+			return fmt.Errorf("map field %s can't be set from the path or query", path)
+		}
+
+		if fd.IsList() {
+			list := msg.Mutable(fd).List()
+			for _, value := range values {
+				v, err := scalarValue(msg, fd, value)
+				if err != nil {
+					return err
+				}
+
+				list.Append(v)
+			}
+
+			return nil
+		}
+
+		v, err := scalarValue(msg, fd, values[len(values)-1])
+		if err != nil {
+			return err
+		}
+
+		msg.Set(fd, v)
+	}
+
+	return nil
+}
+
+func scalarValue(msg protoreflect.Message, fd protoreflect.FieldDescriptor, value string) (protoreflect.Value, error) {
+	invalid := func(err error) (protoreflect.Value, error) {
+		return protoreflect.Value{}, fmt.Errorf("invalid value %q of %s: %w", value, fd.Name(), err)
+	}
+
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(value), nil
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(value)), nil
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return invalid(err)
+		}
+
+		return protoreflect.ValueOfBool(v), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return invalid(err)
+		}
+
+		return protoreflect.ValueOfInt32(int32(v)), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return invalid(err)
+		}
+
+		return protoreflect.ValueOfInt64(v), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return invalid(err)
+		}
+
+		return protoreflect.ValueOfUint32(uint32(v)), nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return invalid(err)
+		}
+
+		return protoreflect.ValueOfUint64(v), nil
+	case protoreflect.FloatKind:
+		v, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return invalid(err)
+		}
+
+		return protoreflect.ValueOfFloat32(float32(v)), nil
+	case protoreflect.DoubleKind:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return invalid(err)
+		}
+
+		return protoreflect.ValueOfFloat64(v), nil
+	case protoreflect.EnumKind:
+		if v := fd.Enum().Values().ByName(protoreflect.Name(value)); v != nil {
+			return protoreflect.ValueOfEnum(v.Number()), nil
+		}
+
+		v, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return invalid(err)
+		}
+
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), nil
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		// the well known types, e.g. google.protobuf.Timestamp, are read from their JSON string
+		v := msg.NewField(fd)
+		if err := protojson.Unmarshal([]byte(strconv.Quote(value)), v.Message().Interface()); err != nil {
+			return invalid(err)
+		}
+
+		return v, nil
+	}
+
+	return invalid(fmt.Errorf("unsupported kind %s", fd.Kind()))
+}
+
+// gatewayHeaders are not forwarded to the gRPC metadata
+var gatewayHeaders = map[string]bool{
+	"connection":        true,
+	"content-length":    true,
+	"content-type":      true,
+	"host":              true,
+	"keep-alive":        true,
+	"te":                true,
+	"trailer":           true,
+	"transfer-encoding": true,
+	"upgrade":           true,
+}
+
+// gatewayMetadata forwards the http headers, e.g. Authorization and X-Request-Id, as the gRPC metadata
+func gatewayMetadata(r *http.Request) metadata.MD {
+	md := metadata.MD{}
+	for name, values := range r.Header {
+		key := strings.ToLower(name)
+		if gatewayHeaders[key] || strings.HasPrefix(key, "grpc-") {
+			continue
+		}
+
+		md.Append(key, values...)
+	}
+
+	return md
+}
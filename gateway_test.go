@@ -0,0 +1,163 @@
+package gema
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestRouteFromRule(t *testing.T) {
+	tests := []struct {
+		rule    *annotations.HttpRule
+		method  string
+		path    string
+		field   string
+		prefix  string
+		matches []string
+		rejects []string
+		ok      bool
+		wantErr bool
+	}{
+		{rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/v1/users/{id}"}}, method: http.MethodGet, path: "/v1/users/:id", ok: true},
+		{rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Post{Post: "/v1/users"}, Body: "*"}, method: http.MethodPost, path: "/v1/users", ok: true},
+		{rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Delete{Delete: "/v1/users/{user.id=*}/posts/{post_id}"}}, method: http.MethodDelete, path: "/v1/users/:user.id/posts/:post_id", ok: true},
+		{rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Custom{Custom: &annotations.CustomHttpPattern{Kind: "HEAD", Path: "/v1/ping"}}}, method: "HEAD", path: "/v1/ping", ok: true},
+		{
+			rule:    &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/v1/{name=shelves/*}"}},
+			method:  http.MethodGet,
+			path:    "/v1/shelves/*",
+			field:   "name",
+			prefix:  "shelves/",
+			matches: []string{"shelves/1"},
+			rejects: []string{"shelves/", "shelves/1/books/2"},
+			ok:      true,
+		},
+		{
+			rule:    &annotations.HttpRule{Pattern: &annotations.HttpRule_Patch{Patch: "/v1/{book.name=shelves/*/books/*}"}},
+			method:  http.MethodPatch,
+			path:    "/v1/shelves/*",
+			field:   "book.name",
+			prefix:  "shelves/",
+			matches: []string{"shelves/1/books/2"},
+			rejects: []string{"shelves/1", "shelves/1/authors/2"},
+			ok:      true,
+		},
+		{
+			rule:    &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/v1/files/{path=**}"}},
+			method:  http.MethodGet,
+			path:    "/v1/files/*",
+			field:   "path",
+			matches: []string{"a", "a/b/c.txt"},
+			ok:      true,
+		},
+		{rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/v1/{name=shelves/*}/books"}}, wantErr: true},
+		{rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/v1/{name=shelves}"}}, wantErr: true},
+		{rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/v1/{name=**/books}"}}, wantErr: true},
+		{rule: &annotations.HttpRule{}},
+	}
+
+	for _, tt := range tests {
+		route, ok, err := routeFromRule(tt.rule, "/test.Service/Method")
+		if (err != nil) != tt.wantErr || ok != tt.ok {
+			t.Errorf("routeFromRule(%v) = %v, %v, want ok %v, error %v", tt.rule, ok, err, tt.ok, tt.wantErr)
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		if route.Method != tt.method || route.Path != tt.path || route.GrpcMethod != "/test.Service/Method" || route.Body != tt.rule.Body {
+			t.Errorf("routeFromRule(%v) = %+v, want %s %s", tt.rule, route.GatewayRoute, tt.method, tt.path)
+		}
+
+		if tt.field == "" {
+			if route.wildcard != nil {
+				t.Errorf("routeFromRule(%v) has wildcard %+v", tt.rule, route.wildcard)
+			}
+
+			continue
+		}
+
+		if route.wildcard == nil || route.wildcard.field != tt.field || route.wildcard.prefix != tt.prefix {
+			t.Errorf("routeFromRule(%v) wildcard = %+v, want field %q prefix %q", tt.rule, route.wildcard, tt.field, tt.prefix)
+			continue
+		}
+
+		for _, value := range tt.matches {
+			if !route.wildcard.pattern.MatchString(value) {
+				t.Errorf("wildcard of %v should match %q", tt.rule, value)
+			}
+		}
+
+		for _, value := range tt.rejects {
+			if route.wildcard.pattern.MatchString(value) {
+				t.Errorf("wildcard of %v should not match %q", tt.rule, value)
+			}
+		}
+	}
+}
+
+func TestSetMessageField(t *testing.T) {
+	msg := &descriptorpb.FileDescriptorProto{}
+	fields := []struct {
+		path   string
+		values []string
+	}{
+		{"name", []string{"first.proto", "last.proto"}},
+		{"dependency", []string{"a.proto", "b.proto"}},
+		{"publicDependency", []string{"1"}},
+		{"public_dependency", []string{"2"}},
+		{"options.java_package", []string{"com.example"}},
+		{"options.optimizeFor", []string{"CODE_SIZE"}},
+		{"options.cc_enable_arenas", []string{"true"}},
+	}
+
+	for _, f := range fields {
+		if err := setMessageField(msg.ProtoReflect(), f.path, f.values); err != nil {
+			t.Fatalf("setMessageField(%s, %q) = %v", f.path, f.values, err)
+		}
+	}
+
+	want := &descriptorpb.FileDescriptorProto{
+		Name:             proto.String("last.proto"),
+		Dependency:       []string{"a.proto", "b.proto"},
+		PublicDependency: []int32{1, 2},
+		Options: &descriptorpb.FileOptions{
+			JavaPackage:    proto.String("com.example"),
+			OptimizeFor:    descriptorpb.FileOptions_CODE_SIZE.Enum(),
+			CcEnableArenas: proto.Bool(true),
+		},
+	}
+
+	if !proto.Equal(msg, want) {
+		t.Errorf("setMessageField result = %v, want %v", msg, want)
+	}
+
+	errs := []struct {
+		path  string
+		value string
+	}{
+		{"unknown", "x"},
+		{"options.unknown", "x"},
+		{"name.value", "x"},
+		{"message_type.name", "x"},
+		{"public_dependency", "x"},
+		{"options.optimize_for", "FAST"},
+		{"options.cc_enable_arenas", "maybe"},
+	}
+
+	for _, tt := range errs {
+		if err := setMessageField(msg.ProtoReflect(), tt.path, []string{tt.value}); err == nil {
+			t.Errorf("setMessageField(%s, %q) should fail", tt.path, tt.value)
+		}
+	}
+
+	if err := setMessageField(msg.ProtoReflect(), "unknown", []string{"x"}); !errors.Is(err, errUnknownField) {
+		t.Errorf("setMessageField(unknown) = %v, want errUnknownField", err)
+	}
+}
@@ -1,8 +1,9 @@
 package gema
 
 import (
+	"context"
 	"fmt"
-	"net"
+	"sort"
 
 	"go.uber.org/fx"
 	"google.golang.org/grpc"
@@ -20,37 +21,191 @@ func AsGrpcService(constructor any) any {
 	)
 }
 
+// UnaryInterceptor is a gRPC unary server interceptor. The interceptors are chained by their order,
+// the lowest order is the outermost one. Interceptors with the same order are chained in unspecified order
+type UnaryInterceptor interface {
+	Order() int
+	InterceptUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error)
+}
+
+// StreamInterceptor is a gRPC stream server interceptor, chained the same way as UnaryInterceptor
+type StreamInterceptor interface {
+	Order() int
+	InterceptStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error
+}
+
+func AsUnaryInterceptor(constructor any) any {
+	return fx.Annotate(
+		constructor,
+		fx.As(new(UnaryInterceptor)),
+		fx.ResultTags(`group:"grpc_unary_interceptors"`),
+	)
+}
+
+func AsStreamInterceptor(constructor any) any {
+	return fx.Annotate(
+		constructor,
+		fx.As(new(StreamInterceptor)),
+		fx.ResultTags(`group:"grpc_stream_interceptors"`),
+	)
+}
+
+type unaryInterceptorFunc struct {
+	order int
+	fn    grpc.UnaryServerInterceptor
+}
+
+// UnaryInterceptorFunc adapts an ordinary grpc.UnaryServerInterceptor into UnaryInterceptor
+func UnaryInterceptorFunc(order int, fn grpc.UnaryServerInterceptor) UnaryInterceptor {
+	return &unaryInterceptorFunc{order, fn}
+}
+
+func (u *unaryInterceptorFunc) Order() int {
+	return u.order
+}
+
+func (u *unaryInterceptorFunc) InterceptUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return u.fn(ctx, req, info, handler)
+}
+
+type streamInterceptorFunc struct {
+	order int
+	fn    grpc.StreamServerInterceptor
+}
+
+// StreamInterceptorFunc adapts an ordinary grpc.StreamServerInterceptor into StreamInterceptor
+func StreamInterceptorFunc(order int, fn grpc.StreamServerInterceptor) StreamInterceptor {
+	return &streamInterceptorFunc{order, fn}
+}
+
+func (s *streamInterceptorFunc) Order() int {
+	return s.order
+}
+
+func (s *streamInterceptorFunc) InterceptStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return s.fn(srv, ss, info, handler)
+}
+
+type grpcServerParams struct {
+	fx.In
+
+	UnaryInterceptors  []UnaryInterceptor  `group:"grpc_unary_interceptors"`
+	StreamInterceptors []StreamInterceptor `group:"grpc_stream_interceptors"`
+}
+
+// GrpcServerModule provides the *grpc.Server with the interceptors registered
+// with AsUnaryInterceptor and AsStreamInterceptor chained by their order.
+// The request messages are validated the same way as the http requests, and the invalid
+// ones are rejected with codes.InvalidArgument and the google.rpc.BadRequest details.
+// The errors returned by the handlers are mapped to the gRPC status, see Error.
+// The services registered with AsGrpcService are registered once, so the server can be shared by
+// StartGrpc, StartHTTPAndGrpc and StartGateway. A server provided by the application instead
+// gets the services registered by each of them, so it can only be used by one
+func GrpcServerModule(opts ...grpc.ServerOption) fx.Option {
+	return fx.Module("grpc_server",
+		fx.Provide(
+			AsUnaryInterceptor(newGrpcValidator),
+			AsStreamInterceptor(newGrpcValidator),
+			AsUnaryInterceptor(newGrpcErrorMapper),
+			AsStreamInterceptor(newGrpcErrorMapper),
+		),
+		fx.Provide(func(p grpcServerParams) *grpc.Server {
+			sort.SliceStable(p.UnaryInterceptors, func(i, j int) bool {
+				return p.UnaryInterceptors[i].Order() < p.UnaryInterceptors[j].Order()
+			})
+
+			sort.SliceStable(p.StreamInterceptors, func(i, j int) bool {
+				return p.StreamInterceptors[i].Order() < p.StreamInterceptors[j].Order()
+			})
+
+			unary := make([]grpc.UnaryServerInterceptor, 0, len(p.UnaryInterceptors))
+			for _, interceptor := range p.UnaryInterceptors {
+				unary = append(unary, interceptor.InterceptUnary)
+			}
+
+			stream := make([]grpc.StreamServerInterceptor, 0, len(p.StreamInterceptors))
+			for _, interceptor := range p.StreamInterceptors {
+				stream = append(stream, interceptor.InterceptStream)
+			}
+
+			options := append([]grpc.ServerOption{
+				grpc.ChainUnaryInterceptor(unary...),
+				grpc.ChainStreamInterceptor(stream...),
+			}, opts...)
+
+			return grpc.NewServer(options...)
+		}),
+		fx.Provide(fx.Annotate(newGrpcServices, fx.ParamTags("", `group:"grpc_services"`))),
+	)
+}
+
 type grpcParams struct {
 	fx.In
 
 	fx.Lifecycle
+	Shutdowner fx.Shutdowner
 	*grpc.Server
-	Services []GrpcService `group:"grpc_services"`
+	Registered *grpcServices   `optional:"true"`
+	Services   []GrpcService   `group:"grpc_services"`
+	Checkers   []HealthChecker `group:"health_checkers"`
 }
 
-func StartGrpc(host, port string) fx.Option {
+// StartGrpc will register the services and the health service to the gRPC server and start it.
+// Unix socket and systemd addresses are passed as the host with empty port, see StartHTTP
+func StartGrpc(host, port string, opts ...ServerOption) fx.Option {
+	config := newServerConfig(opts)
 	return fx.Module("start_grpc",
 		fx.Invoke(func(p grpcParams) {
-			for _, service := range p.Services {
-				service.Register(p.Server)
-			}
+			registerGrpcServices(p.Registered, p.Server, p.Services)
+			health := newHealthService(p.Server, p.Checkers)
 
 			p.Append(fx.StartHook(func() error {
 				fmt.Println("[Gema] Starting gRPC server on " + host + port)
-				listener, err := net.Listen("tcp", host+port)
+				listener, err := config.listen(host+port, "h2")
 				if err != nil {
 					return err
 				}
 
 				go func() {
 					if err := p.Server.Serve(listener); err != nil {
-						fmt.Println("[Gema] gRPC server stopped with error: " + err.Error())
+						shutdownOnError(p.Shutdowner, "gRPC server", err)
 					}
 				}()
+
+				if health != nil {
+					health.start()
+				}
+
 				return nil
 			}))
 
-			p.Append(fx.StopHook(p.GracefulStop))
+			p.Append(fx.StopHook(func() {
+				if health != nil {
+					health.stop()
+				}
+
+				p.GracefulStop()
+			}))
 		}),
 	)
 }
+
+// grpcServices marks the services as registered to the server of GrpcServerModule. It is provided once,
+// so StartGrpc, StartHTTPAndGrpc, StartGateway and ServicesCommand can depend on it and share the server
+type grpcServices struct{}
+
+func newGrpcServices(server *grpc.Server, services []GrpcService) *grpcServices {
+	for _, service := range services {
+		service.Register(server)
+	}
+
+	return &grpcServices{}
+}
+
+// registerGrpcServices registers the services to the server provided by the application,
+// unless they are already registered by GrpcServerModule
+func registerGrpcServices(registered *grpcServices, server *grpc.Server, services []GrpcService) {
+	if registered == nil {
+		newGrpcServices(server, services)
+	}
+}
@@ -0,0 +1,243 @@
+package gema
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const defaultGrpcClientTimeout = 10 * time.Second
+
+type grpcClientConfig struct {
+	timeout     time.Duration
+	maxAttempts int
+	retryCodes  []codes.Code
+	bufconn     *bufconn.Listener
+	dialOptions []grpc.DialOption
+}
+
+// GrpcClientOption configures the connection of GrpcClientModule
+type GrpcClientOption func(c *grpcClientConfig)
+
+// WithClientTimeout sets the deadline of the calls whose context has no deadline. Defaults to 10 seconds,
+// zero disables it
+func WithClientTimeout(timeout time.Duration) GrpcClientOption {
+	return func(c *grpcClientConfig) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetryPolicy retries the calls failed with the retryable codes up to maxAttempts, including the first call.
+// Defaults to 3 attempts for codes.Unavailable, maxAttempts less than 2 disables the retry
+func WithRetryPolicy(maxAttempts int, retryable ...codes.Code) GrpcClientOption {
+	return func(c *grpcClientConfig) {
+		c.maxAttempts = maxAttempts
+		c.retryCodes = retryable
+	}
+}
+
+// WithBufconn connects to an in-process server listening on the bufconn listener instead of the target,
+// e.g. to test the client against your GrpcService
+func WithBufconn(listener *bufconn.Listener) GrpcClientOption {
+	return func(c *grpcClientConfig) {
+		c.bufconn = listener
+	}
+}
+
+// WithDialOptions adds the dial options, e.g. grpc.WithTransportCredentials. The connection is insecure by default
+func WithDialOptions(opts ...grpc.DialOption) GrpcClientOption {
+	return func(c *grpcClientConfig) {
+		c.dialOptions = append(c.dialOptions, opts...)
+	}
+}
+
+// GrpcClientModule provides the *grpc.ClientConn and grpc.ClientConnInterface to the target named by the name,
+// to be used by AsGrpcClient. The connection is closed when the app stops. The calls are logged if the
+// *zap.Logger is provided, the X-Request-Id of the incoming gRPC call is propagated, and the calls are given
+// the default deadline and retry policy:
+//
+//	gema.GrpcClientModule("user", env.USER_SERVICE_ADDR),
+//	fx.Provide(gema.AsGrpcClient("user", pb.NewUserServiceClient)),
+func GrpcClientModule(name, target string, opts ...GrpcClientOption) fx.Option {
+	config := &grpcClientConfig{
+		timeout:     defaultGrpcClientTimeout,
+		maxAttempts: 3,
+		retryCodes:  []codes.Code{codes.Unavailable},
+	}
+
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	nameTag := fmt.Sprintf(`name:"%s"`, name)
+	return fx.Module("grpc_client."+name,
+		fx.Provide(
+			fx.Annotate(
+				func(lc fx.Lifecycle, logger *zap.Logger) (*grpc.ClientConn, error) {
+					conn, err := newGrpcClientConn(target, config, logger)
+					if err != nil {
+						return nil, err
+					}
+
+					lc.Append(fx.StopHook(conn.Close))
+					return conn, nil
+				},
+				fx.ParamTags("", `optional:"true"`),
+				fx.ResultTags(nameTag),
+			),
+			fx.Annotate(
+				func(conn *grpc.ClientConn) grpc.ClientConnInterface {
+					return conn
+				},
+				fx.ParamTags(nameTag),
+				fx.ResultTags(nameTag),
+			),
+		),
+	)
+}
+
+// AsGrpcClient provides the client stub created by the constructor, e.g. pb.NewUserServiceClient,
+// with the connection of the GrpcClientModule of the same name
+func AsGrpcClient(name string, constructor any) any {
+	return fx.Annotate(
+		constructor,
+		fx.ParamTags(fmt.Sprintf(`name:"%s"`, name)),
+	)
+}
+
+func newGrpcClientConn(target string, config *grpcClientConfig, logger *zap.Logger) (*grpc.ClientConn, error) {
+	unary := []grpc.UnaryClientInterceptor{requestIDUnaryClient, deadlineUnaryClient(config.timeout)}
+	stream := []grpc.StreamClientInterceptor{requestIDStreamClient}
+	if logger != nil {
+		client := &grpcClientLogger{logger}
+		unary = append([]grpc.UnaryClientInterceptor{client.interceptUnary}, unary...)
+		stream = append([]grpc.StreamClientInterceptor{client.interceptStream}, stream...)
+	}
+
+	options := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(unary...),
+		grpc.WithChainStreamInterceptor(stream...),
+	}
+
+	if config.maxAttempts > 1 && len(config.retryCodes) > 0 {
+		options = append(options, grpc.WithDefaultServiceConfig(retryServiceConfig(config.maxAttempts, config.retryCodes)))
+	}
+
+	if config.bufconn != nil {
+		target = "passthrough:///" + target
+		options = append(options, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return config.bufconn.DialContext(ctx)
+		}))
+	}
+
+	return grpc.NewClient(target, append(options, config.dialOptions...)...)
+}
+
+func retryServiceConfig(maxAttempts int, retryable []codes.Code) string {
+	values := make([]string, 0, len(retryable))
+	for _, code := range retryable {
+		values = append(values, strconv.Itoa(int(code)))
+	}
+
+	// gRPC limits the attempts to 5
+	return fmt.Sprintf(`{"methodConfig": [{
+		"name": [{}],
+		"retryPolicy": {
+			"maxAttempts": %d,
+			"initialBackoff": "0.1s",
+			"maxBackoff": "1s",
+			"backoffMultiplier": 2,
+			"retryableStatusCodes": [%s]
+		}
+	}]}`, min(maxAttempts, 5), strings.Join(values, ", "))
+}
+
+// outgoingRequestID copies the X-Request-Id of the incoming call to the outgoing call
+func outgoingRequestID(ctx context.Context) context.Context {
+	if out, ok := metadata.FromOutgoingContext(ctx); ok && len(out.Get(echo.HeaderXRequestID)) > 0 {
+		return ctx
+	}
+
+	in, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	if values := in.Get(echo.HeaderXRequestID); len(values) > 0 {
+		return metadata.AppendToOutgoingContext(ctx, echo.HeaderXRequestID, values[0])
+	}
+
+	return ctx
+}
+
+func requestIDUnaryClient(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(outgoingRequestID(ctx), method, req, reply, cc, opts...)
+}
+
+func requestIDStreamClient(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(outgoingRequestID(ctx), desc, cc, method, opts...)
+}
+
+// deadlineUnaryClient sets the timeout to the calls without deadline. The streams are left as is,
+// since they are usually long-lived
+func deadlineUnaryClient(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+type grpcClientLogger struct {
+	logger *zap.Logger
+}
+
+func (l *grpcClientLogger) interceptUnary(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	l.log(method, cc.Target(), start, err)
+
+	return err
+}
+
+func (l *grpcClientLogger) interceptStream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	start := time.Now()
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	l.log(method, cc.Target(), start, err)
+
+	return stream, err
+}
+
+func (l *grpcClientLogger) log(method, target string, start time.Time, err error) {
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("target", target),
+		zap.String("code", status.Code(err).String()),
+		zap.String("latency", time.Since(start).String()),
+	}
+
+	if err != nil {
+		l.logger.With(zap.Error(err)).Warn("gRPC call failed", fields...)
+		return
+	}
+
+	l.logger.Debug("gRPC call", fields...)
+}
@@ -0,0 +1,41 @@
+package gema
+
+import (
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"google.golang.org/grpc"
+)
+
+type countingService struct {
+	registered int
+}
+
+func (s *countingService) Register(server *grpc.Server) {
+	s.registered++
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "gema.test.Counting",
+		HandlerType: (*any)(nil),
+	}, s)
+}
+
+func TestGrpcServicesRegisteredOnce(t *testing.T) {
+	service := &countingService{}
+	app := fxtest.New(t,
+		fx.NopLogger,
+		fx.Provide(echo.New),
+		fx.Provide(AsGrpcService(func() *countingService { return service })),
+		GrpcServerModule(),
+		StartGrpc("127.0.0.1", ":0"),
+		StartGateway(),
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+
+	if service.registered != 1 {
+		t.Errorf("service is registered %d times, want 1", service.registered)
+	}
+}
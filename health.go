@@ -0,0 +1,155 @@
+package gema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/riverqueue/river"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	healthCheckInterval = 10 * time.Second
+	healthCheckTimeout  = 5 * time.Second
+)
+
+// HealthChecker reports the health of a dependency, e.g. the database. The service is the name
+// reported by the gRPC health service, and it is NOT_SERVING whenever Check returns an error
+type HealthChecker interface {
+	Service() string
+	Check(ctx context.Context) error
+}
+
+func AsHealthChecker(constructor any) any {
+	return fx.Annotate(
+		constructor,
+		fx.As(new(HealthChecker)),
+		fx.ResultTags(`group:"health_checkers"`),
+	)
+}
+
+type healthCheckerFunc struct {
+	service string
+	check   func(ctx context.Context) error
+}
+
+// NewHealthChecker creates a HealthChecker of the service from a check function
+func NewHealthChecker(service string, check func(ctx context.Context) error) HealthChecker {
+	return &healthCheckerFunc{service, check}
+}
+
+func (h *healthCheckerFunc) Service() string {
+	return h.service
+}
+
+func (h *healthCheckerFunc) Check(ctx context.Context) error {
+	return h.check(ctx)
+}
+
+func newQueueHealthChecker(client *river.Client[pgx.Tx]) HealthChecker {
+	return NewHealthChecker("queue", func(ctx context.Context) error {
+		select {
+		case <-client.Stopped():
+			return errors.New("[Gema] Queue client is stopped")
+		default:
+			return nil
+		}
+	})
+}
+
+// healthService serves grpc.health.v1.Health. Each checker sets the status of its own service,
+// while the overall status ("") and the registered gRPC services are SERVING only when every checker passes
+type healthService struct {
+	server   *health.Server
+	grpc     *grpc.Server
+	checkers []HealthChecker
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// newHealthService registers the health service to server, unless it is already registered by the application
+func newHealthService(server *grpc.Server, checkers []HealthChecker) *healthService {
+	if _, ok := server.GetServiceInfo()[healthpb.Health_ServiceDesc.ServiceName]; ok {
+		return nil
+	}
+
+	h := &healthService{
+		server:   health.NewServer(),
+		grpc:     server,
+		checkers: checkers,
+	}
+
+	healthpb.RegisterHealthServer(server, h.server)
+	return h
+}
+
+func (h *healthService) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	h.check(ctx)
+
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+
+		ticker := time.NewTicker(healthCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.check(ctx)
+			}
+		}
+	}()
+}
+
+// stop sets every service to NOT_SERVING, so the clients stop sending new requests during the graceful stop
+func (h *healthService) stop() {
+	h.server.Shutdown()
+	if h.cancel != nil {
+		h.cancel()
+	}
+
+	h.wg.Wait()
+}
+
+func (h *healthService) check(ctx context.Context) {
+	healthy := true
+	for _, checker := range h.checkers {
+		checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		err := checker.Check(checkCtx)
+		cancel()
+
+		status := healthpb.HealthCheckResponse_SERVING
+		if err != nil {
+			fmt.Printf("[Gema] Health check of %s failed: %v\n", checker.Service(), err)
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+			healthy = false
+		}
+
+		h.server.SetServingStatus(checker.Service(), status)
+	}
+
+	status := healthpb.HealthCheckResponse_SERVING
+	if !healthy {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	h.server.SetServingStatus("", status)
+	for name := range h.grpc.GetServiceInfo() {
+		if name != healthpb.Health_ServiceDesc.ServiceName {
+			h.server.SetServingStatus(name, status)
+		}
+	}
+}
@@ -2,8 +2,10 @@ package gema
 
 import (
 	"context"
-	"fmt"
 	"net/http"
+	"path"
+	"sort"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
 	"go.uber.org/fx"
@@ -21,31 +23,89 @@ func AsController(constructor any) any {
 	)
 }
 
+// ControllerPrefix is an optional interface of the Controller to prefix its routes, e.g. /users
+type ControllerPrefix interface {
+	Prefix() string
+}
+
+// ControllerVersion is an optional interface of the Controller to serve its routes under the API version,
+// e.g. v1. Multiple versions of the same routes can be served side by side by different controllers,
+// and the old versions can be marked with DeprecateVersion. The routes are grouped as
+// /{api prefix}/{version}/{controller prefix}, e.g. /api/v1/users
+type ControllerVersion interface {
+	Version() string
+}
+
+// ControllerMiddleware is an optional interface of the Controller to apply the middleware to its own routes only
+type ControllerMiddleware interface {
+	Middleware() []echo.MiddlewareFunc
+}
+
+// Middleware is an http middleware applied to every route. The middleware are applied by their order,
+// the lowest order is the outermost one. Middleware with the same order are applied in unspecified order
+type Middleware interface {
+	Order() int
+	Handle(next echo.HandlerFunc) echo.HandlerFunc
+}
+
+func AsMiddleware(constructor any) any {
+	return fx.Annotate(
+		constructor,
+		fx.As(new(Middleware)),
+		fx.ResultTags(`group:"middlewares"`),
+	)
+}
+
+type middlewareFunc struct {
+	order int
+	fn    echo.MiddlewareFunc
+}
+
+// MiddlewareFunc adapts an ordinary echo.MiddlewareFunc into Middleware, e.g. middleware.Recover()
+func MiddlewareFunc(order int, fn echo.MiddlewareFunc) Middleware {
+	return &middlewareFunc{order, fn}
+}
+
+func (m *middlewareFunc) Order() int {
+	return m.order
+}
+
+func (m *middlewareFunc) Handle(next echo.HandlerFunc) echo.HandlerFunc {
+	return m.fn(next)
+}
+
 type httpParams struct {
 	fx.In
 
 	*echo.Echo
 	fx.Lifecycle
+	Shutdowner  fx.Shutdowner
 	Controllers []Controller `group:"controllers"`
+	Middlewares []Middleware `group:"middlewares"`
 }
 
 // StartHTTP will start the echo server and register the controllers
 // to the echo instance. It will also create custom binder for added validation
-// and serializer for the echo instance
-func StartHTTP(address string) fx.Option {
+// and serializer for the echo instance, and the error handler rendering Error.
+// The address can be host:port, unix:///path/to/app.sock or systemd://name
+func StartHTTP(address string, opts ...ServerOption) fx.Option {
+	config := newServerConfig(opts)
 	return fx.Module("start_http",
-		fx.Invoke(registerCustomBinder),
-		fx.Invoke(registerCustomSerializer),
+		setupHTTP(),
 		fx.Invoke(func(p httpParams) {
-			for _, controller := range p.Controllers {
-				controller.CreateRoutes(p.Group(""))
-			}
+			registerControllers(p.Echo, p.Controllers, config)
 
 			p.Append(fx.Hook{
 				OnStart: func(ctx context.Context) error {
+					listener, err := config.listen(address, "h2", "http/1.1")
+					if err != nil {
+						return err
+					}
+
+					p.Listener = listener
 					go func() {
 						if err := p.Start(address); err != nil && err != http.ErrServerClosed {
-							fmt.Println("[Gema] Http server stopped with error: ", err)
+							shutdownOnError(p.Shutdowner, "Http server", err)
 						}
 					}()
 
@@ -56,3 +116,113 @@ func StartHTTP(address string) fx.Option {
 		}),
 	)
 }
+
+// setupHTTP registers the custom binder, error handler, serializer and middleware to the echo instance
+func setupHTTP() fx.Option {
+	return fx.Options(
+		fx.Invoke(registerCustomBinder),
+		fx.Invoke(registerErrorHandler),
+		fx.Invoke(registerCustomSerializer),
+		fx.Invoke(registerMiddlewares),
+	)
+}
+
+type middlewareParams struct {
+	fx.In
+
+	*echo.Echo
+	Middlewares []Middleware `group:"middlewares"`
+}
+
+func registerMiddlewares(p middlewareParams) {
+	for _, middleware := range sortMiddlewares(p.Middlewares) {
+		p.Use(middleware.Handle)
+	}
+}
+
+func sortMiddlewares(middlewares []Middleware) []Middleware {
+	sorted := append([]Middleware(nil), middlewares...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Order() < sorted[j].Order()
+	})
+
+	return sorted
+}
+
+// registerControllers gives every controller its own group. The middleware of the ControllerMiddleware
+// only apply to the routes of the controller, not to the unmatched paths under the group
+func registerControllers(e *echo.Echo, controllers []Controller, config *serverConfig) {
+	for _, controller := range controllers {
+		var version, prefix string
+		if c, ok := controller.(ControllerVersion); ok {
+			version = c.Version()
+		}
+
+		if c, ok := controller.(ControllerPrefix); ok {
+			prefix = c.Prefix()
+		}
+
+		var middleware []echo.MiddlewareFunc
+		if d, ok := config.deprecations[version]; ok && version != "" {
+			middleware = append(middleware, deprecationMiddleware(d))
+		}
+
+		if c, ok := controller.(ControllerMiddleware); ok {
+			middleware = append(middleware, c.Middleware()...)
+		}
+
+		group, done := controllerGroup(e, groupPath(config.apiPrefix, version, prefix), middleware)
+		controller.CreateRoutes(group)
+		done()
+	}
+}
+
+// controllerGroup returns the group whose routes are added to e along with the middleware of each route.
+// Unlike e.Group, the middleware are not given to echo.Group.Use, which also adds catch all routes
+// running them for every unmatched path. The routes are recorded on a private echo instance instead,
+// and done copies the names given to the recorded routes
+func controllerGroup(e *echo.Echo, prefix string, middleware []echo.MiddlewareFunc) (*echo.Group, func()) {
+	if len(middleware) == 0 {
+		return e.Group(prefix), func() {}
+	}
+
+	recorder := echo.New()
+	group := recorder.Group(prefix, middleware...)
+
+	added := map[string]*echo.Route{}
+	recorder.OnAddRouteHandler = func(_ string, route echo.Route, handler echo.HandlerFunc, middleware []echo.MiddlewareFunc) {
+		added[route.Method+route.Path] = e.Add(route.Method, route.Path, handler, middleware...)
+	}
+
+	return group, func() {
+		for _, route := range recorder.Routes() {
+			if r, ok := added[route.Method+route.Path]; ok {
+				r.Name = route.Name
+			}
+		}
+	}
+}
+
+func groupPath(segments ...string) string {
+	p := path.Join(append([]string{"/"}, segments...)...)
+	if p == "/" {
+		return ""
+	}
+
+	return p
+}
+
+// deprecationMiddleware sets the Deprecation (RFC 9745) and Sunset (RFC 8594) headers
+func deprecationMiddleware(d deprecation) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Response().Header()
+			header.Set("Deprecation", "@"+strconv.FormatInt(d.at.Unix(), 10))
+			if !d.sunset.IsZero() {
+				header.Set("Sunset", d.sunset.UTC().Format(http.TimeFormat))
+			}
+
+			return next(c)
+		}
+	}
+}
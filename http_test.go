@@ -0,0 +1,116 @@
+package gema
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type testController struct {
+	prefix  string
+	version string
+	path    string
+	name    string
+}
+
+func (c *testController) Prefix() string {
+	return c.prefix
+}
+
+func (c *testController) Version() string {
+	return c.version
+}
+
+func (c *testController) Middleware() []echo.MiddlewareFunc {
+	return []echo.MiddlewareFunc{requireToken}
+}
+
+func (c *testController) CreateRoutes(r *echo.Group) {
+	r.GET(c.path, func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Response().Header().Get("X-Route"))
+	}, routeHeader).Name = c.name
+}
+
+func requireToken(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Request().Header.Get("Authorization") != "token" {
+			return echo.ErrUnauthorized
+		}
+
+		return next(c)
+	}
+}
+
+func routeHeader(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set("X-Route", "route")
+		return next(c)
+	}
+}
+
+func TestControllerMiddleware(t *testing.T) {
+	e := echo.New()
+	controllers := []Controller{
+		&testController{path: "/me", name: "me"},
+		&testController{path: "/orders", name: "orders"},
+		&testController{prefix: "/users", version: "v1", path: "/:id", name: "user"},
+	}
+
+	deprecatedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	registerControllers(e, controllers, newServerConfig([]ServerOption{
+		WithAPIPrefix("/api"),
+		DeprecateVersion("v1", deprecatedAt, time.Time{}),
+	}))
+
+	tests := []struct {
+		path       string
+		token      bool
+		status     int
+		body       string
+		deprecated bool
+	}{
+		{"/does-not-exist", false, http.StatusNotFound, "", false},
+		{"/api/does-not-exist", false, http.StatusNotFound, "", false},
+		{"/api/users/1/unknown", false, http.StatusNotFound, "", false},
+		{"/api/me", false, http.StatusUnauthorized, "", false},
+		{"/api/me", true, http.StatusOK, "route", false},
+		{"/api/orders", true, http.StatusOK, "route", false},
+		{"/api/v1/users/1", false, http.StatusUnauthorized, "", true},
+		{"/api/v1/users/1", true, http.StatusOK, "route", true},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.token {
+			req.Header.Set("Authorization", "token")
+		}
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if rec.Code != tt.status || (tt.body != "" && rec.Body.String() != tt.body) {
+			t.Errorf("GET %s = %d %q, want %d %q", tt.path, rec.Code, rec.Body.String(), tt.status, tt.body)
+		}
+
+		if deprecated := rec.Header().Get("Deprecation") != ""; deprecated != tt.deprecated {
+			t.Errorf("GET %s deprecated = %v, want %v", tt.path, deprecated, tt.deprecated)
+		}
+	}
+
+	names := map[string]bool{}
+	for _, route := range e.Routes() {
+		names[route.Name] = true
+	}
+
+	for _, name := range []string{"me", "orders", "user"} {
+		if !names[name] {
+			t.Errorf("route %s is not named", name)
+		}
+	}
+
+	if got := e.Reverse("user", "1"); got != "/api/v1/users/1" {
+		t.Errorf("Reverse(user) = %q", got)
+	}
+}
@@ -0,0 +1,193 @@
+package gema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/cobra"
+)
+
+// RouteInfo describes a route registered by the controllers
+type RouteInfo struct {
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Handler    string   `json:"handler"`
+	Middleware []string `json:"middleware"`
+}
+
+// MethodInfo describes a method of a gRPC service
+type MethodInfo struct {
+	Service string `json:"service"`
+	Method  string `json:"method"`
+	Type    string `json:"type"`
+}
+
+// RoutesCommand prints every route registered by the controllers without starting the http server.
+// The middleware registered with AsMiddleware are listed before the ones of the groups and the routes,
+// but not the ones registered directly with echo.Use. Pass the same options as StartHTTP, e.g. WithAPIPrefix,
+// to print the same paths. Wrap it with LazyCommand to only build the http dependencies when it runs
+func RoutesCommand(opts ...ServerOption) CommandConstructor {
+	config := newServerConfig(opts)
+	return func(p httpParams) *cobra.Command {
+		var output string
+		routesCmd := &cobra.Command{
+			Use:     "routes",
+			Short:   "Print the registered http routes",
+			Example: "  routes\n" + "  routes --output json",
+			Args:    cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				routes := collectRoutes(p.Echo, p.Controllers, p.Middlewares, config)
+				if output == "json" {
+					return printJSON(cmd.OutOrStdout(), routes)
+				}
+
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "METHOD\tPATH\tHANDLER\tMIDDLEWARE")
+				for _, route := range routes {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", route.Method, route.Path, route.Handler, strings.Join(route.Middleware, ", "))
+				}
+
+				return w.Flush()
+			},
+		}
+
+		routesCmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table or json")
+		return routesCmd
+	}
+}
+
+// ServicesCommand prints every gRPC service and method registered by the GrpcService without starting the gRPC server.
+// Wrap it with LazyCommand to only build the gRPC dependencies when it runs
+func ServicesCommand() CommandConstructor {
+	return func(p grpcParams) *cobra.Command {
+		var output string
+		servicesCmd := &cobra.Command{
+			Use:     "services",
+			Short:   "Print the registered gRPC services",
+			Example: "  services\n" + "  services --output json",
+			Args:    cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				registerGrpcServices(p.Registered, p.Server, p.Services)
+
+				var methods []MethodInfo
+				for service, info := range p.Server.GetServiceInfo() {
+					for _, method := range info.Methods {
+						methods = append(methods, MethodInfo{
+							Service: service,
+							Method:  method.Name,
+							Type:    streamType(method.IsClientStream, method.IsServerStream),
+						})
+					}
+				}
+
+				sort.Slice(methods, func(i, j int) bool {
+					if methods[i].Service != methods[j].Service {
+						return methods[i].Service < methods[j].Service
+					}
+
+					return methods[i].Method < methods[j].Method
+				})
+
+				if output == "json" {
+					return printJSON(cmd.OutOrStdout(), methods)
+				}
+
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "SERVICE\tMETHOD\tTYPE")
+				for _, method := range methods {
+					fmt.Fprintf(w, "%s\t%s\t%s\n", method.Service, method.Method, method.Type)
+				}
+
+				return w.Flush()
+			},
+		}
+
+		servicesCmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table or json")
+		return servicesCmd
+	}
+}
+
+// collectRoutes registers the controllers to e and records the routes along with their middleware
+func collectRoutes(e *echo.Echo, controllers []Controller, middlewares []Middleware, config *serverConfig) []RouteInfo {
+	var routes []RouteInfo
+
+	var global []string
+	for _, mw := range sortMiddlewares(middlewares) {
+		global = append(global, middlewareName(mw))
+	}
+
+	previous := e.OnAddRouteHandler
+	e.OnAddRouteHandler = func(host string, route echo.Route, handler echo.HandlerFunc, middleware []echo.MiddlewareFunc) {
+		if previous != nil {
+			previous(host, route, handler, middleware)
+		}
+
+		// catch all routes registered by group middleware
+		if route.Method == echo.RouteNotFound {
+			return
+		}
+
+		names := append(make([]string, 0, len(global)+len(middleware)), global...)
+		for _, mw := range middleware {
+			names = append(names, funcName(mw))
+		}
+
+		routes = append(routes, RouteInfo{
+			Method:     route.Method,
+			Path:       route.Path,
+			Handler:    route.Name,
+			Middleware: names,
+		})
+	}
+	defer func() { e.OnAddRouteHandler = previous }()
+
+	registerControllers(e, controllers, config)
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+
+		return routes[i].Method < routes[j].Method
+	})
+
+	return routes
+}
+
+func funcName(fn any) string {
+	return runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+}
+
+func middlewareName(mw Middleware) string {
+	if fn, ok := mw.(*middlewareFunc); ok {
+		return funcName(fn.fn)
+	}
+
+	return fmt.Sprintf("%T", mw)
+}
+
+func streamType(client, server bool) string {
+	switch {
+	case client && server:
+		return "bidi-stream"
+	case client:
+		return "client-stream"
+	case server:
+		return "server-stream"
+	default:
+		return "unary"
+	}
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -1,8 +1,10 @@
 package gema
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"runtime/debug"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -10,14 +12,36 @@ import (
 	"go.uber.org/fx/fxevent"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
-// LoggerModule provides a zap logger dependency and use it as echo logger
+// The order of the gRPC interceptors provided by LoggerModule. The logger wraps the recovery,
+// so it logs the panics converted by the recovery interceptor
+const (
+	OrderGrpcLogger   = -200
+	OrderGrpcRecovery = -100
+)
+
+// OrderHTTPLogger is the order of the http logger middleware provided by LoggerModule
+const OrderHTTPLogger = -200
+
+// LoggerModule provides a zap logger dependency and use it as echo logger.
+// It also provides the http logger middleware, and the gRPC logging and panic recovery interceptors for GrpcServerModule.
 // env is the environment, it can be "development" or "production"
 func LoggerModule(env string, options ...zap.Option) fx.Option {
 	return fx.Module("logger",
-		fx.Invoke(registerLoggerMw),
-		fx.Provide(func(lc fx.Lifecycle, e *echo.Echo) *zap.Logger {
+		fx.Provide(
+			AsMiddleware(newLoggerMiddleware),
+			AsUnaryInterceptor(newGrpcLogger),
+			AsStreamInterceptor(newGrpcLogger),
+			AsUnaryInterceptor(newGrpcRecovery),
+			AsStreamInterceptor(newGrpcRecovery),
+		),
+		fx.Provide(func(lc fx.Lifecycle) *zap.Logger {
 			var logger *zap.Logger
 			var err error
 
@@ -41,8 +65,8 @@ func LoggerModule(env string, options ...zap.Option) fx.Option {
 	)
 }
 
-func registerLoggerMw(logger *zap.Logger, e *echo.Echo) {
-	e.Use(loggerMiddleware(logger))
+func newLoggerMiddleware(logger *zap.Logger) Middleware {
+	return MiddlewareFunc(OrderHTTPLogger, loggerMiddleware(logger))
 }
 
 func loggerMiddleware(logger *zap.Logger) echo.MiddlewareFunc {
@@ -91,6 +115,101 @@ func loggerMiddleware(logger *zap.Logger) echo.MiddlewareFunc {
 	}
 }
 
+type grpcLogger struct {
+	logger *zap.Logger
+}
+
+func newGrpcLogger(logger *zap.Logger) *grpcLogger {
+	return &grpcLogger{logger}
+}
+
+func (l *grpcLogger) Order() int {
+	return OrderGrpcLogger
+}
+
+func (l *grpcLogger) InterceptUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	l.log(ctx, info.FullMethod, start, err)
+
+	return resp, err
+}
+
+func (l *grpcLogger) InterceptStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	l.log(ss.Context(), info.FullMethod, start, err)
+
+	return err
+}
+
+func (l *grpcLogger) log(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	fields := []zapcore.Field{
+		zap.String("method", method),
+		zap.String("code", code.String()),
+		zap.String("latency", time.Since(start).String()),
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields = append(fields, zap.String("peer", p.Addr.String()))
+	}
+
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(echo.HeaderXRequestID); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	fields = append(fields, zap.String("request_id", id))
+
+	switch code {
+	case codes.OK:
+		l.logger.Info("Success", fields...)
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.Unauthenticated, codes.FailedPrecondition,
+		codes.OutOfRange, codes.ResourceExhausted, codes.Aborted:
+		l.logger.With(zap.Error(err)).Warn("Client error", fields...)
+	default:
+		l.logger.With(zap.Error(err)).Error("Server error", fields...)
+	}
+}
+
+type grpcRecovery struct {
+	logger *zap.Logger
+}
+
+func newGrpcRecovery(logger *zap.Logger) *grpcRecovery {
+	return &grpcRecovery{logger}
+}
+
+func (r *grpcRecovery) Order() int {
+	return OrderGrpcRecovery
+}
+
+func (r *grpcRecovery) InterceptUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer r.recover(info.FullMethod, &err)
+	return handler(ctx, req)
+}
+
+func (r *grpcRecovery) InterceptStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer r.recover(info.FullMethod, &err)
+	return handler(srv, ss)
+}
+
+// recover converts the panic into codes.Internal error. It must be called directly by defer
+func (r *grpcRecovery) recover(method string, err *error) {
+	if p := recover(); p != nil {
+		r.logger.Error("Panic recovered",
+			zap.String("method", method),
+			zap.Any("panic", p),
+			zap.ByteString("stack", debug.Stack()),
+		)
+
+		*err = status.Error(codes.Internal, "internal server error")
+	}
+}
+
 type fxLogger struct{}
 
 func (l *fxLogger) LogEvent(event fxevent.Event) {
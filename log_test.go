@@ -0,0 +1,22 @@
+package gema
+
+import (
+	"testing"
+
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"google.golang.org/grpc"
+)
+
+func TestLoggerModuleWithoutEcho(t *testing.T) {
+	var server *grpc.Server
+	app := fxtest.New(t,
+		fx.NopLogger,
+		LoggerModule("production"),
+		GrpcServerModule(),
+		fx.Populate(&server),
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+}
@@ -0,0 +1,95 @@
+package gema
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+)
+
+type muxParams struct {
+	fx.In
+
+	fx.Lifecycle
+	Shutdowner fx.Shutdowner
+	*echo.Echo
+	*grpc.Server
+	Controllers []Controller    `group:"controllers"`
+	Registered  *grpcServices   `optional:"true"`
+	Services    []GrpcService   `group:"grpc_services"`
+	Checkers    []HealthChecker `group:"health_checkers"`
+}
+
+// StartHTTPAndGrpc serves both the echo instance and the gRPC server on the same address, e.g. behind
+// a load balancer giving a single port. The gRPC requests, i.e. HTTP/2 with the application/grpc content type,
+// are served by the gRPC server, and the others by echo. Without TLS, HTTP/2 is served as cleartext (h2c).
+// The controllers, services and health service are registered the same way as StartHTTP and StartGrpc
+func StartHTTPAndGrpc(address string, opts ...ServerOption) fx.Option {
+	config := newServerConfig(opts)
+	return fx.Module("start_http_grpc",
+		setupHTTP(),
+		fx.Invoke(func(p muxParams) {
+			registerControllers(p.Echo, p.Controllers, config)
+			registerGrpcServices(p.Registered, p.Server, p.Services)
+			health := newHealthService(p.Server, p.Checkers)
+
+			protocols := new(http.Protocols)
+			protocols.SetHTTP1(true)
+			protocols.SetHTTP2(true)
+			protocols.SetUnencryptedHTTP2(config.tls == nil)
+
+			server := &http.Server{
+				Handler:   muxHandler(p.Echo, p.Server),
+				ErrorLog:  p.StdLogger,
+				Protocols: protocols,
+			}
+
+			p.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					fmt.Println("[Gema] Starting http and gRPC server on " + address)
+					listener, err := config.listen(address, "h2", "http/1.1")
+					if err != nil {
+						return err
+					}
+
+					go func() {
+						if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+							shutdownOnError(p.Shutdowner, "Http and gRPC server", err)
+						}
+					}()
+
+					if health != nil {
+						health.start()
+					}
+
+					return nil
+				},
+				OnStop: func(ctx context.Context) error {
+					if health != nil {
+						health.stop()
+					}
+
+					// the gRPC calls are served as http requests, so the http shutdown waits for them
+					err := server.Shutdown(ctx)
+					p.Server.GracefulStop()
+					return err
+				},
+			})
+		}),
+	)
+}
+
+func muxHandler(e *echo.Echo, server *grpc.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get(echo.HeaderContentType), "application/grpc") {
+			server.ServeHTTP(w, r)
+			return
+		}
+
+		e.ServeHTTP(w, r)
+	})
+}
@@ -1,131 +1,408 @@
 package gema
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Parser interface {
+	// Source reports where the value comes from, e.g. "env" for the process environment,
+	// or the path of the dotenv, secret or config file. It is empty if the value is not set
+	Source() string
+
 	String(defaults ...string) string
 	Int(defaults ...int) int
+
+	// Bool is true only if the value is "true", so BoolE never reports an error
 	Bool(defaults ...bool) bool
 	Float64(defaults ...float64) float64
 	Time(defaults ...time.Time) time.Time
 	Duration(defaults ...time.Duration) time.Duration
+
+	// TimeLayout parses the value as time with the given layout, e.g. time.RFC3339
+	TimeLayout(layout string, defaults ...time.Time) time.Time
+
+	// Strings splits the value by sep, e.g. "a.com, b.com" with sep ","
+	Strings(sep string, defaults ...[]string) []string
+
+	// Ints splits the value by sep and parses each item as int
+	Ints(sep string, defaults ...[]int) []int
+
+	// Map parses comma separated key value pairs, e.g. "k=v,k2=v2"
+	Map(defaults ...map[string]string) map[string]string
+
+	// URL parses the value as an absolute URL
+	URL(defaults ...*url.URL) *url.URL
+
+	// Bytes parses the value as size in bytes, e.g. "512", "10KB", "10MB" or "1GiB".
+	// The units are powers of 1024
+	Bytes(defaults ...int64) int64
+
+	// The E variants behave like their counterparts, but report unparsable values as *EnvError
+	// instead of silently falling back to the default. An empty value is not an error.
+
+	IntE(defaults ...int) (int, error)
+	BoolE(defaults ...bool) (bool, error)
+	Float64E(defaults ...float64) (float64, error)
+	TimeE(defaults ...time.Time) (time.Time, error)
+	DurationE(defaults ...time.Duration) (time.Duration, error)
+	TimeLayoutE(layout string, defaults ...time.Time) (time.Time, error)
+	IntsE(sep string, defaults ...[]int) ([]int, error)
+	MapE(defaults ...map[string]string) (map[string]string, error)
+	URLE(defaults ...*url.URL) (*url.URL, error)
+	BytesE(defaults ...int64) (int64, error)
 }
 
 type parser struct {
-	value string
+	key    string
+	value  string
+	source string
+
+	// must makes the non E variants panic on unparsable value
+	must bool
 }
 
 func parseString(str string) Parser {
-	return &parser{str}
+	return &parser{value: str}
 }
 
-func (p *parser) String(defaults ...string) string {
-	if p.value == "" && len(defaults) > 0 {
-		return defaults[0]
+// parse converts the value of p using conv. If the value is empty, it returns the first default
+// or the zero value. If the conversion fails, it returns the same fallback along with the error
+func parse[T any](p *parser, conv func(string) (T, error), defaults []T) (T, error) {
+	var fallback T
+	if len(defaults) > 0 {
+		fallback = defaults[0]
+		p.recordDefault(fallback)
 	}
 
-	if p.value == "" && len(defaults) == 0 {
-		return ""
+	if p.value == "" {
+		return fallback, nil
 	}
 
-	return p.value
+	result, err := conv(p.value)
+	if err != nil {
+		return fallback, err
+	}
+
+	return result, nil
 }
 
-func (p *parser) Bool(defaults ...bool) bool {
-	if p.value == "" && len(defaults) > 0 {
-		return defaults[0]
+func parseInt(value string) (int, error) {
+	return strconv.Atoi(value)
+}
+
+// parseBool only accepts "true", any other value is false
+func parseBool(value string) (bool, error) {
+	return value == "true", nil
+}
+
+func parseFloat(value string) (float64, error) {
+	return strconv.ParseFloat(value, 64)
+}
+
+func parseTime(value string) (time.Time, error) {
+	return time.Parse(time.DateOnly, value)
+}
+
+func parseTimeLayout(layout string) func(string) (time.Time, error) {
+	return func(value string) (time.Time, error) {
+		return time.Parse(layout, value)
 	}
+}
 
-	if p.value == "" && len(defaults) == 0 {
-		return false
+// splitList splits the value by sep, trims the spaces and skips the empty items
+func splitList(value, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
 
-	return p.value == "true"
+	return items
 }
 
-func (p *parser) Int(defaults ...int) int {
-	if p.value == "" && len(defaults) > 0 {
-		return defaults[0]
+func parseStrings(sep string) func(string) ([]string, error) {
+	return func(value string) ([]string, error) {
+		return splitList(value, sep), nil
 	}
+}
+
+func parseInts(sep string) func(string) ([]int, error) {
+	return func(value string) ([]int, error) {
+		var nums []int
+		for _, item := range splitList(value, sep) {
+			num, err := parseInt(item)
+			if err != nil {
+				return nil, err
+			}
+
+			nums = append(nums, num)
+		}
 
-	if p.value == "" && len(defaults) == 0 {
-		return 0
+		return nums, nil
 	}
+}
 
-	num, err := strconv.Atoi(p.value)
-	if err != nil && len(defaults) > 0 {
-		return defaults[0]
+func parseMap(value string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, pair := range splitList(value, ",") {
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid key value pair %q", pair)
+		}
+
+		result[strings.TrimSpace(key)] = strings.TrimSpace(val)
+	}
+
+	return result, nil
+}
+
+func parseURL(value string) (*url.URL, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, err
 	}
 
-	if err != nil && len(defaults) == 0 {
-		return 0
+	if u.Scheme == "" {
+		return nil, errors.New("missing url scheme")
 	}
 
-	return num
+	return u, nil
 }
 
-func (p *parser) Float64(defaults ...float64) float64 {
-	if p.value == "" && len(defaults) > 0 {
-		return defaults[0]
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	// longer suffixes first, so "MB" is not matched as "B"
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30}, {"TIB", 1 << 40},
+	{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"TB", 1 << 40},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"T", 1 << 40},
+	{"B", 1},
+}
+
+func parseBytes(value string) (int64, error) {
+	upper := strings.ToUpper(strings.TrimSpace(value))
+
+	size := int64(1)
+	for _, unit := range byteUnits {
+		if strings.HasSuffix(upper, unit.suffix) {
+			upper = strings.TrimSpace(strings.TrimSuffix(upper, unit.suffix))
+			size = unit.size
+			break
+		}
 	}
 
-	if p.value == "" && len(defaults) == 0 {
-		return 0
+	num, err := strconv.ParseFloat(upper, 64)
+	if err != nil || math.IsNaN(num) || math.IsInf(num, 0) {
+		return 0, fmt.Errorf("invalid byte size %q", value)
 	}
 
-	num, err := strconv.ParseFloat(p.value, 32)
-	if err != nil && len(defaults) > 0 {
-		return defaults[0]
+	if num < 0 {
+		return 0, fmt.Errorf("negative byte size %q", value)
 	}
 
-	if err != nil && len(defaults) == 0 {
-		return 0
+	// float64(math.MaxInt64) rounds up to 2^63, which already overflows int64
+	bytes := num * float64(size)
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("byte size %q overflows int64", value)
 	}
 
-	return num
+	return int64(bytes), nil
 }
 
-func (p *parser) Time(defaults ...time.Time) time.Time {
-	if p.value == "" && len(defaults) > 0 {
-		return defaults[0]
+// wrap annotates the conversion error with the key and the raw value
+func (p *parser) wrap(err error) error {
+	if err == nil {
+		return nil
 	}
 
-	if p.value == "" && len(defaults) == 0 {
-		return time.Time{}
-	}
+	return &EnvError{Key: p.key, Value: p.value, Err: err}
+}
 
-	date, err := time.Parse("2006-01-02", p.value)
-	if err != nil && len(defaults) > 0 {
-		return defaults[0]
+// check panics on conversion error if the parser is created with MustEnv
+func (p *parser) check(err error) {
+	if err != nil && p.must {
+		panic(fmt.Sprintf("[Gema] Invalid environment variable %v", err))
 	}
+}
 
-	if err != nil && len(defaults) == 0 {
-		return time.Time{}
+// recordDefault registers the default of the key, since it is only known once the value is parsed.
+// It allows ConfigCommand to print the defaults of the keys read with Env
+func (p *parser) recordDefault(value any) {
+	if p.key == "" {
+		return
 	}
 
-	return date
+	registerConfig(configField{key: p.key, defaults: formatDefault(value)})
 }
 
-func (p *parser) Duration(defaults ...time.Duration) time.Duration {
-	if p.value == "" && len(defaults) > 0 {
-		return defaults[0]
+func formatDefault(value any) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case []string:
+		return strings.Join(v, ",")
+	case []int:
+		items := make([]string, 0, len(v))
+		for _, num := range v {
+			items = append(items, strconv.Itoa(num))
+		}
+
+		return strings.Join(items, ",")
+	case map[string]string:
+		pairs := make([]string, 0, len(v))
+		for key, val := range v {
+			pairs = append(pairs, key+"="+val)
+		}
+
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	case *url.URL:
+		if v == nil {
+			return ""
+		}
+
+		return v.String()
 	}
 
-	if p.value == "" && len(defaults) == 0 {
-		return 0
+	return fmt.Sprint(value)
+}
+
+func (p *parser) Source() string {
+	return p.source
+}
+
+func (p *parser) String(defaults ...string) string {
+	if len(defaults) > 0 {
+		p.recordDefault(defaults[0])
 	}
 
-	duration, err := time.ParseDuration(p.value)
-	if err != nil && len(defaults) > 0 {
+	if p.value == "" && len(defaults) > 0 {
 		return defaults[0]
 	}
 
-	if err != nil && len(defaults) == 0 {
-		return 0
-	}
+	return p.value
+}
 
+func (p *parser) Bool(defaults ...bool) bool {
+	b, err := p.BoolE(defaults...)
+	p.check(err)
+	return b
+}
+
+func (p *parser) BoolE(defaults ...bool) (bool, error) {
+	b, err := parse(p, parseBool, defaults)
+	return b, p.wrap(err)
+}
+
+func (p *parser) Int(defaults ...int) int {
+	num, err := p.IntE(defaults...)
+	p.check(err)
+	return num
+}
+
+func (p *parser) IntE(defaults ...int) (int, error) {
+	num, err := parse(p, parseInt, defaults)
+	return num, p.wrap(err)
+}
+
+func (p *parser) Float64(defaults ...float64) float64 {
+	num, err := p.Float64E(defaults...)
+	p.check(err)
+	return num
+}
+
+func (p *parser) Float64E(defaults ...float64) (float64, error) {
+	num, err := parse(p, parseFloat, defaults)
+	return num, p.wrap(err)
+}
+
+func (p *parser) Time(defaults ...time.Time) time.Time {
+	date, err := p.TimeE(defaults...)
+	p.check(err)
+	return date
+}
+
+func (p *parser) TimeE(defaults ...time.Time) (time.Time, error) {
+	date, err := parse(p, parseTime, defaults)
+	return date, p.wrap(err)
+}
+
+func (p *parser) Duration(defaults ...time.Duration) time.Duration {
+	duration, err := p.DurationE(defaults...)
+	p.check(err)
 	return duration
 }
+
+func (p *parser) DurationE(defaults ...time.Duration) (time.Duration, error) {
+	duration, err := parse(p, time.ParseDuration, defaults)
+	return duration, p.wrap(err)
+}
+
+func (p *parser) TimeLayout(layout string, defaults ...time.Time) time.Time {
+	date, err := p.TimeLayoutE(layout, defaults...)
+	p.check(err)
+	return date
+}
+
+func (p *parser) TimeLayoutE(layout string, defaults ...time.Time) (time.Time, error) {
+	date, err := parse(p, parseTimeLayout(layout), defaults)
+	return date, p.wrap(err)
+}
+
+func (p *parser) Strings(sep string, defaults ...[]string) []string {
+	items, _ := parse(p, parseStrings(sep), defaults)
+	return items
+}
+
+func (p *parser) Ints(sep string, defaults ...[]int) []int {
+	nums, err := p.IntsE(sep, defaults...)
+	p.check(err)
+	return nums
+}
+
+func (p *parser) IntsE(sep string, defaults ...[]int) ([]int, error) {
+	nums, err := parse(p, parseInts(sep), defaults)
+	return nums, p.wrap(err)
+}
+
+func (p *parser) Map(defaults ...map[string]string) map[string]string {
+	m, err := p.MapE(defaults...)
+	p.check(err)
+	return m
+}
+
+func (p *parser) MapE(defaults ...map[string]string) (map[string]string, error) {
+	m, err := parse(p, parseMap, defaults)
+	return m, p.wrap(err)
+}
+
+func (p *parser) URL(defaults ...*url.URL) *url.URL {
+	u, err := p.URLE(defaults...)
+	p.check(err)
+	return u
+}
+
+func (p *parser) URLE(defaults ...*url.URL) (*url.URL, error) {
+	u, err := parse(p, parseURL, defaults)
+	return u, p.wrap(err)
+}
+
+func (p *parser) Bytes(defaults ...int64) int64 {
+	size, err := p.BytesE(defaults...)
+	p.check(err)
+	return size
+}
+
+func (p *parser) BytesE(defaults ...int64) (int64, error) {
+	size, err := parse(p, parseBytes, defaults)
+	return size, p.wrap(err)
+}
@@ -0,0 +1,228 @@
+package gema
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParserBool(t *testing.T) {
+	tests := []struct {
+		value    string
+		defaults []bool
+		want     bool
+	}{
+		{"", nil, false},
+		{"", []bool{true}, true},
+		{"true", nil, true},
+		{"true", []bool{false}, true},
+		{"false", []bool{true}, false},
+		{"no", []bool{true}, false},
+		{"off", []bool{true}, false},
+		{"1", nil, false},
+		{"TRUE", nil, false},
+	}
+
+	for _, tt := range tests {
+		p := &parser{key: "FLAG", value: tt.value, must: true}
+		if got := p.Bool(tt.defaults...); got != tt.want {
+			t.Errorf("Bool(%v) with %q = %v, want %v", tt.defaults, tt.value, got, tt.want)
+		}
+
+		got, err := p.BoolE(tt.defaults...)
+		if err != nil || got != tt.want {
+			t.Errorf("BoolE(%v) with %q = %v, %v, want %v", tt.defaults, tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestParserInt(t *testing.T) {
+	tests := []struct {
+		value    string
+		defaults []int
+		want     int
+		wantErr  bool
+	}{
+		{"", nil, 0, false},
+		{"", []int{8001}, 8001, false},
+		{"42", []int{8001}, 42, false},
+		{"-7", nil, -7, false},
+		{"abc", []int{8001}, 8001, true},
+		{"1.5", nil, 0, true},
+	}
+
+	for _, tt := range tests {
+		p := &parser{key: "PORT", value: tt.value}
+		if got := p.Int(tt.defaults...); got != tt.want {
+			t.Errorf("Int(%v) with %q = %v, want %v", tt.defaults, tt.value, got, tt.want)
+		}
+
+		got, err := p.IntE(tt.defaults...)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("IntE(%v) with %q = %v, %v, want %v, error %v", tt.defaults, tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParserErrors(t *testing.T) {
+	_, err := (&parser{key: "PORT", value: "abc"}).IntE()
+
+	var envErr *EnvError
+	if !errors.As(err, &envErr) || envErr.Key != "PORT" || envErr.Value != "abc" {
+		t.Fatalf("IntE error = %v, want *EnvError of PORT", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Int of MustEnv should panic on invalid value")
+		}
+	}()
+
+	(&parser{key: "PORT", value: "abc", must: true}).Int()
+}
+
+func TestParserFloat64(t *testing.T) {
+	tests := []struct {
+		value    string
+		defaults []float64
+		want     float64
+		wantErr  bool
+	}{
+		{"", []float64{0.5}, 0.5, false},
+		{"1.5", nil, 1.5, false},
+		{"0.1", nil, 0.1, false},
+		{"16777217", nil, 16777217, false},
+		{"abc", []float64{0.5}, 0.5, true},
+	}
+
+	for _, tt := range tests {
+		got, err := (&parser{value: tt.value}).Float64E(tt.defaults...)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("Float64E(%v) with %q = %v, %v, want %v, error %v", tt.defaults, tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParserTime(t *testing.T) {
+	fallback := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value   string
+		layout  string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.DateOnly, fallback, false},
+		{"2024-02-29", time.DateOnly, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), false},
+		{"29/02/2024", time.DateOnly, fallback, true},
+		{"2024-02-29T10:00:00Z", time.RFC3339, time.Date(2024, 2, 29, 10, 0, 0, 0, time.UTC), false},
+		{"2024-02-29", time.RFC3339, fallback, true},
+	}
+
+	for _, tt := range tests {
+		got, err := (&parser{value: tt.value}).TimeLayoutE(tt.layout, fallback)
+		if !got.Equal(tt.want) || (err != nil) != tt.wantErr {
+			t.Errorf("TimeLayoutE(%q) with %q = %v, %v, want %v, error %v", tt.layout, tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	if got := (&parser{value: "2024-02-29"}).Time(); !got.Equal(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Time() = %v", got)
+	}
+}
+
+func TestParserDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", time.Second, false},
+		{"1m30s", 90 * time.Second, false},
+		{"90", time.Second, true},
+	}
+
+	for _, tt := range tests {
+		got, err := (&parser{value: tt.value}).DurationE(time.Second)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("DurationE with %q = %v, %v, want %v, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParserLists(t *testing.T) {
+	if got := (&parser{value: " a.com, ,b.com "}).Strings(","); !reflect.DeepEqual(got, []string{"a.com", "b.com"}) {
+		t.Errorf("Strings = %q", got)
+	}
+
+	if got := (&parser{}).Strings(",", []string{"x"}); !reflect.DeepEqual(got, []string{"x"}) {
+		t.Errorf("Strings default = %q", got)
+	}
+
+	nums, err := (&parser{value: "1;2; 3"}).IntsE(";")
+	if err != nil || !reflect.DeepEqual(nums, []int{1, 2, 3}) {
+		t.Errorf("IntsE = %v, %v", nums, err)
+	}
+
+	if _, err := (&parser{value: "1,x"}).IntsE(","); err == nil {
+		t.Error("IntsE with invalid item should fail")
+	}
+
+	m, err := (&parser{value: "a=1, b = 2,c="}).MapE()
+	if err != nil || !reflect.DeepEqual(m, map[string]string{"a": "1", "b": "2", "c": ""}) {
+		t.Errorf("MapE = %v, %v", m, err)
+	}
+
+	if _, err := (&parser{value: "a=1,b"}).MapE(); err == nil {
+		t.Error("MapE without = should fail")
+	}
+}
+
+func TestParserURL(t *testing.T) {
+	u, err := (&parser{value: "https://example.com/api"}).URLE()
+	if err != nil || u.Host != "example.com" || u.Path != "/api" {
+		t.Errorf("URLE = %v, %v", u, err)
+	}
+
+	for _, value := range []string{"example.com", "://bad"} {
+		if _, err := (&parser{value: value}).URLE(); err == nil {
+			t.Errorf("URLE with %q should fail", value)
+		}
+	}
+}
+
+func TestParserBytes(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"", 64, false},
+		{"512", 512, false},
+		{"512B", 512, false},
+		{"10KB", 10 << 10, false},
+		{"10k", 10 << 10, false},
+		{"1.5MB", 3 << 19, false},
+		{"1 GiB", 1 << 30, false},
+		{"2T", 2 << 40, false},
+		{"8EB", 64, true},
+		{"-1KB", 64, true},
+		{"NaN", 64, true},
+		{"Inf", 64, true},
+		{"-Inf", 64, true},
+		{"99999999999TB", 64, true},
+		{"8388608TB", 64, true},
+	}
+
+	for _, tt := range tests {
+		got, err := (&parser{key: "SIZE", value: tt.value}).BytesE(64)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("BytesE with %q = %v, %v, want %v, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	// the largest whole TB below 2^63 is still accepted
+	if got, err := parseBytes("8388607TB"); err != nil || got != 8388607<<40 {
+		t.Errorf("parseBytes(8388607TB) = %v, %v", got, err)
+	}
+}
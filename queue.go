@@ -3,9 +3,8 @@ package gema
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
-	"os"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -15,14 +14,13 @@ import (
 	"go.uber.org/fx"
 )
 
-func newClient(sql *sql.DB) *river.Client[*sql.Tx] {
-	river, err := river.NewClient(riverdatabasesql.New(sql), &river.Config{})
+func newClient(sql *sql.DB) (*river.Client[*sql.Tx], error) {
+	client, err := river.NewClient(riverdatabasesql.New(sql), &river.Config{})
 	if err != nil {
-		fmt.Printf("[Gema] Failed to create River client: %v", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("[Gema] Failed to create River client: %w", err)
 	}
 
-	return river
+	return client, nil
 }
 
 func QueueModule() fx.Option {
@@ -31,17 +29,17 @@ func QueueModule() fx.Option {
 	)
 }
 
-func newServer(queueConfig map[string]river.QueueConfig, pool *pgxpool.Pool, workers *river.Workers) *river.Client[pgx.Tx] {
+func newServer(queueConfig map[string]river.QueueConfig, pool *pgxpool.Pool, workers *river.Workers) (*river.Client[pgx.Tx], error) {
 	client, err := river.NewClient(riverpgxv5.New(pool), &river.Config{
 		Queues:  queueConfig,
 		Workers: workers,
 	})
 
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("[Gema] Failed to create River client: %w", err)
 	}
 
-	return client
+	return client, nil
 }
 
 type QueueWorker interface {
@@ -60,16 +58,19 @@ type queueParams struct {
 	fx.In
 
 	fx.Lifecycle
+	Shutdowner fx.Shutdowner
 	*river.Client[pgx.Tx]
 	*river.Workers
 	QueueWorker []QueueWorker `group:"workers"`
 }
 
+// StartQueue starts the river client with the workers registered with AsWorker
 func StartQueue(queueConfig map[string]river.QueueConfig) fx.Option {
 	return fx.Module("start_queue",
 		fx.Supply(queueConfig),
 		fx.Supply(river.NewWorkers()),
 		fx.Provide(fx.Private, newServer),
+		fx.Provide(AsHealthChecker(newQueueHealthChecker)),
 		fx.Invoke(func(p queueParams) {
 			for _, worker := range p.QueueWorker {
 				worker.Register(p.Workers)
@@ -78,7 +79,20 @@ func StartQueue(queueConfig map[string]river.QueueConfig) fx.Option {
 			ctx, cancel := context.WithCancel(context.Background())
 			p.Append(fx.Hook{
 				OnStart: func(_ context.Context) error {
-					return p.Start(ctx)
+					if err := p.Start(ctx); err != nil {
+						return err
+					}
+
+					// the client stops by itself if it fails, e.g. losing the database connection
+					stopped := p.Stopped()
+					go func() {
+						<-stopped
+						if ctx.Err() == nil {
+							shutdownOnError(p.Shutdowner, "Queue client", errors.New("stopped unexpectedly"))
+						}
+					}()
+
+					return nil
 				},
 				OnStop: func(stopCtx context.Context) error {
 					cancel()
@@ -0,0 +1,482 @@
+package gema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	goparser "go/parser"
+	"go/scanner"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/spf13/cobra"
+)
+
+// MakeCommands registers the code generator commands to the root command. The modules are generated
+// inside dir following the layout of a gema module (module.go, controller.go, service.go, store.go and api/):
+//
+//	make:module <module>
+//	make:controller <module> <name>
+//	make:worker <module> <name>
+//	make:grpc-service <module> <name>
+//	make:seeder <module> <name>
+//
+// The generated controllers, workers and gRPC services are added to the fx.Module of the module.go
+func MakeCommands(dir string) CommandOption {
+	return func(root *cobra.Command) {
+		root.AddCommand(
+			makeModuleCmd(dir),
+			makeComponentCmd(dir, "controller", "Create a new controller", controllerTemplate, "AsController"),
+			makeComponentCmd(dir, "worker", "Create a new queue worker", workerTemplate, "AsWorker"),
+			makeComponentCmd(dir, "grpc-service", "Create a new gRPC service", grpcServiceTemplate, "AsGrpcService"),
+			makeComponentCmd(dir, "seeder", "Create a new database seeder", seederTemplate, ""),
+		)
+	}
+}
+
+type scaffold struct {
+	Package string
+
+	// Name is the unexported identifier, Type is the exported one, and Route is the kebab-case one
+	Name  string
+	Type  string
+	Route string
+}
+
+func newScaffold(module, name string) scaffold {
+	words := splitWords(name)
+	return scaffold{
+		Package: strings.ToLower(strings.Join(splitWords(module), "")),
+		Name:    lowerFirst(camelCase(words)),
+		Type:    camelCase(words),
+		Route:   strings.Join(words, "-"),
+	}
+}
+
+func makeModuleCmd(dir string) *cobra.Command {
+	return &cobra.Command{
+		Use:     "make:module",
+		Short:   "Create a new module",
+		Example: "  make:module <module>",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s := newScaffold(args[0], args[0])
+			moduleDir := filepath.Join(dir, s.Package)
+
+			files := map[string]string{
+				"module.go":                           moduleTemplate,
+				"controller.go":                       moduleControllerTemplate,
+				"service.go":                          serviceTemplate,
+				"store.go":                            storeTemplate,
+				filepath.Join("api", s.Package+".go"): apiTemplate,
+			}
+
+			for name, tmpl := range files {
+				if err := generate(filepath.Join(moduleDir, name), tmpl, s); err != nil {
+					return err
+				}
+
+				cmd.Println("[Gema] Created", filepath.Join(moduleDir, name))
+			}
+
+			return nil
+		},
+	}
+}
+
+// makeComponentCmd generates a file inside an existing module, and registers the constructor
+// to the module with the annotation, e.g. AsController. Empty annotation skips the registration
+func makeComponentCmd(dir, kind, short, tmpl, annotation string) *cobra.Command {
+	return &cobra.Command{
+		Use:     "make:" + kind,
+		Short:   short,
+		Example: "  make:" + kind + " <module> <name>",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s := newScaffold(args[0], args[1])
+			moduleDir := filepath.Join(dir, s.Package)
+
+			suffix := strings.ReplaceAll(kind, "-", "_")
+			file := filepath.Join(moduleDir, snakeCase(splitWords(args[1]))+"_"+suffix+".go")
+			if err := generate(file, tmpl, s); err != nil {
+				return err
+			}
+
+			cmd.Println("[Gema] Created", file)
+			if annotation == "" {
+				cmd.Printf("[Gema] Register %s.New%sSeeder() to your SeederCommand\n", s.Package, s.Type)
+				return nil
+			}
+
+			constructor := "new" + s.Type + camelCase(splitWords(kind))
+			provider := fmt.Sprintf("fx.Provide(gema.%s(%s))", annotation, constructor)
+			if err := addProvider(filepath.Join(moduleDir, "module.go"), provider); err != nil {
+				return err
+			}
+
+			cmd.Println("[Gema] Registered", constructor, "to", filepath.Join(moduleDir, "module.go"))
+			return nil
+		},
+	}
+}
+
+func generate(file, tmpl string, s scaffold) error {
+	if _, err := os.Stat(file); err == nil {
+		return fmt.Errorf("[Gema] %s already exists", file)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := template.Must(template.New(file).Parse(tmpl)).Execute(&buf, s); err != nil {
+		return err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, src, 0644)
+}
+
+// addProvider appends the provider to the arguments of the fx.Module call inside the module file
+func addProvider(file, provider string) error {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	fset := token.NewFileSet()
+	f, err := goparser.ParseFile(fset, file, src, goparser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	var call *ast.CallExpr
+	ast.Inspect(f, func(n ast.Node) bool {
+		if c, ok := n.(*ast.CallExpr); ok && call == nil {
+			if sel, ok := c.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Module" {
+				if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "fx" {
+					call = c
+				}
+			}
+		}
+
+		return call == nil
+	})
+
+	if call == nil || len(call.Args) == 0 {
+		return fmt.Errorf("[Gema] fx.Module is not found in %s", file)
+	}
+
+	// the provider follows the trailing comma on its own line if the arguments span multiple lines,
+	// otherwise it is appended right after the last argument, before any comment
+	rparen := fset.Position(call.Rparen).Offset
+	lastArg := fset.Position(call.Args[len(call.Args)-1].End()).Offset
+	out := string(src[:lastArg]) + ", " + provider + string(src[lastArg:])
+	if hasComma(src[lastArg:rparen]) {
+		out = string(src[:rparen]) + provider + ",\n" + string(src[rparen:])
+	}
+
+	if !hasImport(f, "github.com/thoriqadillah/gema") {
+		pkgEnd := fset.Position(f.Name.End()).Offset
+		out = out[:pkgEnd] + "\n\nimport \"github.com/thoriqadillah/gema\"" + out[pkgEnd:]
+	}
+
+	formatted, err := format.Source([]byte(out))
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, formatted, 0644)
+}
+
+// hasComma reports whether the source has a comma token, ignoring the commas inside the comments
+func hasComma(src []byte) bool {
+	var s scanner.Scanner
+	s.Init(token.NewFileSet().AddFile("", -1, len(src)), src, nil, 0)
+	for {
+		_, tok, _ := s.Scan()
+		switch tok {
+		case token.COMMA:
+			return true
+		case token.EOF:
+			return false
+		}
+	}
+}
+
+func hasImport(f *ast.File, path string) bool {
+	for _, imp := range f.Imports {
+		if strings.Trim(imp.Path.Value, `"`) == path {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitWords splits snake_case, kebab-case, camelCase and PascalCase names into lower cased words
+func splitWords(name string) []string {
+	var words []string
+	var current []rune
+
+	runes := []rune(name)
+	for i, r := range runes {
+		if r == '_' || r == '-' || unicode.IsSpace(r) {
+			if len(current) > 0 {
+				words = append(words, string(current))
+				current = nil
+			}
+
+			continue
+		}
+
+		if unicode.IsUpper(r) && len(current) > 0 {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				words = append(words, string(current))
+				current = nil
+			}
+		}
+
+		current = append(current, unicode.ToLower(r))
+	}
+
+	if len(current) > 0 {
+		words = append(words, string(current))
+	}
+
+	return words
+}
+
+func camelCase(words []string) string {
+	var b strings.Builder
+	for _, word := range words {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+
+	return b.String()
+}
+
+func snakeCase(words []string) string {
+	return strings.Join(words, "_")
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+const moduleTemplate = `package {{.Package}}
+
+import (
+	"github.com/thoriqadillah/gema"
+	"go.uber.org/fx"
+)
+
+func NewModule() fx.Option {
+	return fx.Module("{{.Package}}",
+		fx.Provide(fx.Private, newStore),
+		fx.Provide(newService),
+		fx.Provide(gema.AsController(newController)),
+	)
+}
+`
+
+const moduleControllerTemplate = `package {{.Package}}
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/thoriqadillah/gema"
+)
+
+type {{.Name}}Controller struct {
+	svc *{{.Type}}Service
+}
+
+func newController(svc *{{.Type}}Service) gema.Controller {
+	return &{{.Name}}Controller{
+		svc: svc,
+	}
+}
+
+func (ctl *{{.Name}}Controller) hello(c echo.Context) error {
+	return c.String(http.StatusOK, ctl.svc.Hello(c.Request().Context()))
+}
+
+func (ctl *{{.Name}}Controller) CreateRoutes(r *echo.Group) {
+	r.GET("/{{.Route}}", ctl.hello)
+}
+`
+
+const serviceTemplate = `package {{.Package}}
+
+import (
+	"context"
+)
+
+type {{.Type}}Service struct {
+	store Store
+}
+
+func newService(store Store) *{{.Type}}Service {
+	return &{{.Type}}Service{
+		store: store,
+	}
+}
+
+func (s *{{.Type}}Service) Hello(ctx context.Context) string {
+	return s.store.Hello(ctx)
+}
+`
+
+const storeTemplate = `package {{.Package}}
+
+import (
+	"context"
+
+	"github.com/thoriqadillah/gema"
+)
+
+type Store interface {
+	Hello(ctx context.Context) string
+}
+
+type store struct {
+	db *gema.DB
+}
+
+func newStore(db *gema.DB) Store {
+	return &store{db}
+}
+
+func (s *store) Hello(ctx context.Context) string {
+	db := s.db.Tx(ctx)
+	_ = db
+
+	// do something with db
+	return "Hello world"
+}
+`
+
+const apiTemplate = `package api
+
+import "github.com/thoriqadillah/gema"
+
+type {{.Type}}Request struct {
+	gema.Validate
+}
+`
+
+const controllerTemplate = `package {{.Package}}
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/thoriqadillah/gema"
+)
+
+type {{.Name}}Controller struct{}
+
+func new{{.Type}}Controller() gema.Controller {
+	return &{{.Name}}Controller{}
+}
+
+func (ctl *{{.Name}}Controller) index(c echo.Context) error {
+	return c.NoContent(http.StatusOK)
+}
+
+func (ctl *{{.Name}}Controller) CreateRoutes(r *echo.Group) {
+	r.GET("/{{.Route}}", ctl.index)
+}
+`
+
+const workerTemplate = `package {{.Package}}
+
+import (
+	"context"
+
+	"github.com/riverqueue/river"
+	"github.com/thoriqadillah/gema"
+)
+
+type {{.Type}}Arg struct{}
+
+func ({{.Type}}Arg) Kind() string {
+	return "{{.Route}}"
+}
+
+type {{.Type}}Worker struct {
+	river.WorkerDefaults[{{.Type}}Arg]
+}
+
+func new{{.Type}}Worker() gema.QueueWorker {
+	return &{{.Type}}Worker{}
+}
+
+func (w *{{.Type}}Worker) Work(ctx context.Context, job *river.Job[{{.Type}}Arg]) error {
+	return nil
+}
+
+func (w *{{.Type}}Worker) Register(workers *river.Workers) {
+	river.AddWorker(workers, w)
+}
+`
+
+const grpcServiceTemplate = `package {{.Package}}
+
+import (
+	"github.com/thoriqadillah/gema"
+	"google.golang.org/grpc"
+)
+
+type {{.Name}}GrpcService struct{}
+
+func new{{.Type}}GrpcService() gema.GrpcService {
+	return &{{.Name}}GrpcService{}
+}
+
+func (s *{{.Name}}GrpcService) Register(server *grpc.Server) {
+	// register your generated service server, e.g. pb.Register{{.Type}}Server(server, s)
+}
+`
+
+const seederTemplate = `package {{.Package}}
+
+import (
+	"context"
+
+	"github.com/thoriqadillah/gema"
+	"github.com/uptrace/bun"
+)
+
+type {{.Name}}Seeder struct{}
+
+func New{{.Type}}Seeder() gema.Seeder {
+	return &{{.Name}}Seeder{}
+}
+
+func (s *{{.Name}}Seeder) Seed(ctx context.Context, tx *bun.Tx) error {
+	return nil
+}
+`
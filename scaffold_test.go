@@ -0,0 +1,149 @@
+package gema
+
+import (
+	"bytes"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func runMake(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	root := &cobra.Command{Use: "gema"}
+	MakeCommands(dir)(root)
+	root.SetOut(&bytes.Buffer{})
+	root.SetArgs(args)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("%v: %v", args, err)
+	}
+}
+
+func readModule(t *testing.T, file string) string {
+	t.Helper()
+
+	src, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	formatted, err := format.Source(src)
+	if err != nil {
+		t.Fatalf("%s is not valid Go: %v\n%s", file, err, src)
+	}
+
+	if !bytes.Equal(formatted, src) {
+		t.Errorf("%s is not formatted:\n%s", file, src)
+	}
+
+	return string(src)
+}
+
+func TestMakeController(t *testing.T) {
+	dir := t.TempDir()
+	runMake(t, dir, "make:module", "user")
+	runMake(t, dir, "make:controller", "user", "profile")
+	runMake(t, dir, "make:worker", "user", "send_email")
+
+	module := readModule(t, filepath.Join(dir, "user", "module.go"))
+	for _, provider := range []string{
+		"fx.Provide(gema.AsController(newController)),",
+		"fx.Provide(gema.AsController(newProfileController)),",
+		"fx.Provide(gema.AsWorker(newSendEmailWorker)),",
+	} {
+		if strings.Count(module, provider) != 1 {
+			t.Errorf("module.go should contain %s once:\n%s", provider, module)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "user", "profile_controller.go")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAddProvider(t *testing.T) {
+	tests := []struct {
+		name   string
+		module string
+		want   string
+	}{
+		{
+			name: "single line",
+			module: `package user
+
+import "go.uber.org/fx"
+
+func NewModule() fx.Option {
+	return fx.Module("user", fx.Provide(newService))
+}
+`,
+			want: `fx.Module("user", fx.Provide(newService), fx.Provide(gema.AsController(newProfileController)))`,
+		},
+		{
+			name: "trailing comma",
+			module: `package user
+
+import "go.uber.org/fx"
+
+func NewModule() fx.Option {
+	return fx.Module("user",
+		fx.Provide(newService), // the service, and its store
+	)
+}
+`,
+			want: "\t\tfx.Provide(newService), // the service, and its store\n\t\tfx.Provide(gema.AsController(newProfileController)),\n\t)",
+		},
+		{
+			name: "closing parenthesis after the last argument",
+			module: `package user
+
+import "go.uber.org/fx"
+
+func NewModule() fx.Option {
+	return fx.Module("user",
+		fx.Provide(newService))
+}
+`,
+			want: "\t\tfx.Provide(newService), fx.Provide(gema.AsController(newProfileController)))",
+		},
+		{
+			name: "comma inside the comment",
+			module: `package user
+
+import "go.uber.org/fx"
+
+func NewModule() fx.Option {
+	return fx.Module("user", fx.Provide(newService) /* the service, and its store */)
+}
+`,
+			want: "fx.Provide(newService), fx.Provide(gema.AsController(newProfileController)) /* the service, and its store */)",
+		},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "user", "module.go")
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(file, []byte(tt.module), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		runMake(t, dir, "make:controller", "user", "profile")
+
+		module := readModule(t, file)
+		if !strings.Contains(module, tt.want) {
+			t.Errorf("%s: module.go should contain %q:\n%s", tt.name, tt.want, module)
+		}
+
+		if !strings.Contains(module, `"github.com/thoriqadillah/gema"`) {
+			t.Errorf("%s: module.go should import gema:\n%s", tt.name, module)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package gema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/riverqueue/river"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+)
+
+const (
+	RoleAPI    = "api"
+	RoleGrpc   = "grpc"
+	RoleWorker = "worker"
+)
+
+// ServeRole is a named part of the application that can be started by ServeCommand
+type ServeRole struct {
+	Name   string
+	Option fx.Option
+}
+
+// HTTPRole starts the http server as the "api" role
+func HTTPRole(address string, opts ...ServerOption) ServeRole {
+	return ServeRole{RoleAPI, StartHTTP(address, opts...)}
+}
+
+// GrpcRole starts the gRPC server as the "grpc" role
+func GrpcRole(host, port string, opts ...ServerOption) ServeRole {
+	return ServeRole{RoleGrpc, StartGrpc(host, port, opts...)}
+}
+
+// WorkerRole starts the queue workers as the "worker" role
+func WorkerRole(queueConfig map[string]river.QueueConfig) ServeRole {
+	return ServeRole{RoleWorker, StartQueue(queueConfig)}
+}
+
+// ServeCommand starts the selected roles in a new fx app along with the shared modules,
+// e.g. the logger, the database and your feature modules. The roles are selected with
+// the --role flag, and all of them are started by default. It allows the same binary to run
+// as an API node or a worker-only node:
+//
+//	serve --role=api,grpc
+//	serve --role=worker
+func ServeCommand(modules fx.Option, roles ...ServeRole) CommandConstructor {
+	return func() *cobra.Command {
+		names := make([]string, 0, len(roles))
+		for _, role := range roles {
+			names = append(names, role.Name)
+		}
+
+		var selected []string
+		serveCmd := &cobra.Command{
+			Use:     "serve",
+			Short:   "Start the servers",
+			Example: "  serve\n" + "  serve --role=" + strings.Join(names, ","),
+			Args:    cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				options := []fx.Option{modules}
+				for _, name := range selected {
+					role, ok := findRole(roles, name)
+					if !ok {
+						return fmt.Errorf("[Gema] Unknown role %q, available roles: %s", name, strings.Join(names, ", "))
+					}
+
+					options = append(options, role.Option)
+				}
+
+				return serve(cmd.Context(), fx.New(options...))
+			},
+		}
+
+		serveCmd.Flags().StringSliceVar(&selected, "role", names, "roles to start: "+strings.Join(names, ", "))
+		return serveCmd
+	}
+}
+
+func findRole(roles []ServeRole, name string) (ServeRole, bool) {
+	for _, role := range roles {
+		if role.Name == strings.TrimSpace(name) {
+			return role, true
+		}
+	}
+
+	return ServeRole{}, false
+}
+
+// serve runs the app until ctx is done or the app is shut down
+func serve(ctx context.Context, app *fx.App) error {
+	startCtx, cancel := context.WithTimeout(ctx, app.StartTimeout())
+	defer cancel()
+
+	if err := app.Start(startCtx); err != nil {
+		return err
+	}
+
+	var exitErr error
+	select {
+	case <-ctx.Done():
+	case sig := <-app.Wait():
+		if sig.ExitCode != 0 {
+			exitErr = fmt.Errorf("[Gema] Server stopped with exit code %d", sig.ExitCode)
+		}
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), app.StopTimeout())
+	defer cancel()
+
+	return errors.Join(exitErr, app.Stop(stopCtx))
+}
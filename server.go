@@ -0,0 +1,183 @@
+package gema
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+type serverConfig struct {
+	tls          *TLSConfig
+	apiPrefix    string
+	deprecations map[string]deprecation
+}
+
+type deprecation struct {
+	at     time.Time
+	sunset time.Time
+}
+
+// ServerOption configures the server started by StartHTTP and StartGrpc
+type ServerOption func(c *serverConfig)
+
+func newServerConfig(opts []ServerOption) *serverConfig {
+	c := &serverConfig{deprecations: map[string]deprecation{}}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// WithTLS serves over TLS, or mTLS if the client CA is configured
+func WithTLS(config *TLSConfig) ServerOption {
+	return func(c *serverConfig) {
+		c.tls = config
+	}
+}
+
+// WithAPIPrefix prefixes the routes of every controller, e.g. /api
+func WithAPIPrefix(prefix string) ServerOption {
+	return func(c *serverConfig) {
+		c.apiPrefix = prefix
+	}
+}
+
+// DeprecateVersion marks the routes of the controllers of the version, see ControllerVersion, as deprecated.
+// Their responses have the Deprecation header, and the Sunset header unless sunset is zero
+func DeprecateVersion(version string, deprecatedAt, sunset time.Time) ServerOption {
+	return func(c *serverConfig) {
+		c.deprecations[version] = deprecation{deprecatedAt, sunset}
+	}
+}
+
+// listen creates the listener of the address, see listen, wrapped with TLS if it is configured
+func (c *serverConfig) listen(address string, nextProtos ...string) (net.Listener, error) {
+	if c.tls != nil {
+		if err := c.tls.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := listen(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.tls != nil {
+		listener = tls.NewListener(listener, c.tls.serverConfig(nextProtos...))
+	}
+
+	return listener, nil
+}
+
+// listen creates the listener of the address:
+//
+//   - host:port listens on TCP
+//   - unix:///run/app.sock listens on the unix socket, the stale socket of the previous process is removed
+//   - systemd://name or systemd://index inherits the listener passed by the systemd socket activation,
+//     matched by the FileDescriptorName of the socket unit or by its index
+func listen(address string) (net.Listener, error) {
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		return listenUnix(strings.TrimPrefix(address, "unix://"))
+	case strings.HasPrefix(address, "systemd://"):
+		return listenSystemd(strings.TrimPrefix(address, "systemd://"))
+	}
+
+	return net.Listen("tcp", address)
+}
+
+func listenUnix(path string) (net.Listener, error) {
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		// the socket is stale if nobody accepts the connection
+		conn, err := net.Dial("unix", path)
+		if err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("[Gema] Unix socket %s is already in use", path)
+		}
+
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return net.Listen("unix", path)
+}
+
+// systemdListeners are the inherited file descriptors, each of them can only be used once
+var systemdListeners = struct {
+	sync.Mutex
+	used map[int]bool
+}{used: map[int]bool{}}
+
+// listenSystemd returns the listener passed with LISTEN_FDS, LISTEN_PID and LISTEN_FDNAMES.
+// The file descriptors start from 3, see sd_listen_fds(3)
+func listenSystemd(name string) (net.Listener, error) {
+	const firstFd = 3
+
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, fmt.Errorf("[Gema] No listener is passed by systemd to this process")
+	}
+
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("[Gema] No listener is passed by systemd to this process")
+	}
+
+	index := -1
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	for i := 0; i < count && i < len(names); i++ {
+		if names[i] == name {
+			index = i
+			break
+		}
+	}
+
+	if index < 0 {
+		if i, err := strconv.Atoi(name); err == nil && i >= 0 && i < count {
+			index = i
+		}
+	}
+
+	if index < 0 {
+		return nil, fmt.Errorf("[Gema] Listener %q is not passed by systemd", name)
+	}
+
+	systemdListeners.Lock()
+	defer systemdListeners.Unlock()
+
+	fd := firstFd + index
+	if systemdListeners.used[fd] {
+		return nil, fmt.Errorf("[Gema] Listener %q passed by systemd is already used", name)
+	}
+
+	// FileListener duplicates the file descriptor, so the inherited one is closed
+	file := os.NewFile(uintptr(fd), name)
+	defer file.Close()
+
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, err
+	}
+
+	systemdListeners.used[fd] = true
+	return listener, nil
+}
+
+// shutdownOnError shuts the app down with exit code 1 when the server of StartHTTP, StartGrpc,
+// StartHTTPAndGrpc or the river client of StartQueue stops with error, instead of leaving
+// the process alive but serving nothing
+func shutdownOnError(sh fx.Shutdowner, server string, err error) {
+	fmt.Printf("[Gema] %s stopped with error: %v\n", server, err)
+	if err := sh.Shutdown(fx.ExitCode(1)); err != nil {
+		fmt.Println("[Gema] Failed to shut down: ", err)
+	}
+}
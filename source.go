@@ -0,0 +1,233 @@
+package gema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigSource resolves the configuration values for gema.Env
+type ConfigSource interface {
+	// Lookup returns the raw value of key and where it comes from
+	Lookup(key string) (value, origin string, ok bool)
+}
+
+// FileSource is a ConfigSource backed by files on disk.
+// Load is called when the source is registered, and again whenever the files need to be re-read
+type FileSource interface {
+	ConfigSource
+	Files() []string
+	Load() error
+}
+
+var configSources = struct {
+	sync.RWMutex
+	list []ConfigSource
+}{list: []ConfigSource{ProcessEnvSource(), SecretFileSource()}}
+
+// SetConfigSources replaces the sources used by gema.Env. The sources are looked up in the given order,
+// so the first one has the highest precedence. By default, gema.Env resolves from ProcessEnvSource
+// followed by SecretFileSource
+func SetConfigSources(sources ...ConfigSource) error {
+	for _, source := range sources {
+		if fileSource, ok := source.(FileSource); ok {
+			if err := fileSource.Load(); err != nil {
+				return err
+			}
+		}
+	}
+
+	configSources.Lock()
+	defer configSources.Unlock()
+
+	configSources.list = sources
+	return nil
+}
+
+func lookupSources(key string) (value, origin string, ok bool) {
+	configSources.RLock()
+	defer configSources.RUnlock()
+
+	for _, source := range configSources.list {
+		if value, origin, ok := source.Lookup(key); ok {
+			return value, origin, true
+		}
+	}
+
+	return "", "", false
+}
+
+func fileSources() []FileSource {
+	configSources.RLock()
+	defer configSources.RUnlock()
+
+	var sources []FileSource
+	for _, source := range configSources.list {
+		if fileSource, ok := source.(FileSource); ok {
+			sources = append(sources, fileSource)
+		}
+	}
+
+	return sources
+}
+
+type processEnvSource struct{}
+
+// ProcessEnvSource resolves from the process environment, including the values loaded by LoadDotenv
+func ProcessEnvSource() ConfigSource {
+	return processEnvSource{}
+}
+
+func (processEnvSource) Lookup(key string) (string, string, bool) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return "", "", false
+	}
+
+	if file, ok := envOrigin(key); ok {
+		return value, file, true
+	}
+
+	return value, "env", true
+}
+
+type secretFileSource struct{}
+
+// SecretFileSource resolves KEY from the file pointed by the KEY_FILE environment variable,
+// e.g. DB_PASSWORD_FILE=/run/secrets/db_password as used by Docker secrets.
+// The trailing newline of the file is trimmed, and unreadable files are treated as not set
+func SecretFileSource() ConfigSource {
+	return secretFileSource{}
+}
+
+func (secretFileSource) Lookup(key string) (string, string, bool) {
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return "", "", false
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", false
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), path, true
+}
+
+// mapSource is a FileSource that flattens a decoded document into environment variable keys
+type mapSource struct {
+	path   string
+	decode func(content []byte, out *map[string]any) error
+
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+// JSONFileSource resolves from a JSON file. Nested objects are flattened into upper cased keys
+// joined by underscore, so {"db": {"url": "..."}} is resolved as DB_URL, and arrays are joined by comma.
+// A missing file is treated as empty
+func JSONFileSource(path string) FileSource {
+	return &mapSource{path: path, decode: func(content []byte, out *map[string]any) error {
+		// numbers are kept as written, instead of float64 printed as 1.048576e+07
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		if err := decoder.Decode(out); err != nil {
+			return err
+		}
+
+		if _, err := decoder.Token(); err != io.EOF {
+			return errors.New("unexpected content after the JSON document")
+		}
+
+		return nil
+	}}
+}
+
+// YAMLFileSource resolves from a YAML file, flattened the same way as JSONFileSource
+func YAMLFileSource(path string) FileSource {
+	return &mapSource{path: path, decode: func(content []byte, out *map[string]any) error {
+		return yaml.Unmarshal(content, out)
+	}}
+}
+
+func (m *mapSource) Files() []string {
+	return []string{m.path}
+}
+
+func (m *mapSource) Load() error {
+	values := map[string]string{}
+
+	content, err := os.ReadFile(m.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if len(content) > 0 {
+		var doc map[string]any
+		if err := m.decode(content, &doc); err != nil {
+			return fmt.Errorf("[Gema] Failed to parse config file %s: %w", m.path, err)
+		}
+
+		flatten("", doc, values)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.values = values
+	return nil
+}
+
+func (m *mapSource) Lookup(key string) (string, string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value, ok := m.values[key]
+	return value, m.path, ok
+}
+
+func flatten(prefix string, node any, out map[string]string) {
+	switch v := node.(type) {
+	case map[string]any:
+		for key, child := range v {
+			name := strings.ToUpper(key)
+			if prefix != "" {
+				name = prefix + "_" + name
+			}
+
+			flatten(name, child, out)
+		}
+
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, scalarString(item))
+		}
+
+		out[prefix] = strings.Join(items, ",")
+
+	case nil:
+		// null values are treated as not set
+
+	default:
+		out[prefix] = scalarString(v)
+	}
+}
+
+// scalarString formats the decoded value, e.g. YAML floats are not printed in exponent form
+func scalarString(v any) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,58 @@
+package gema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSourceNumbers(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		source  func(path string) FileSource
+		file    string
+		content string
+	}{
+		{JSONFileSource, "config.json", `{"upload": {"limit": 10485760, "ratio": 0.5, "big": 9007199254740993, "ports": [8000, 8001]}}`},
+		{YAMLFileSource, "config.yaml", "upload:\n  limit: 10485760\n  ratio: 0.5\n  big: 9007199254740993\n  ports: [8000, 8001]\n"},
+		{YAMLFileSource, "float.yaml", "upload:\n  limit: 10485760.0\n  ratio: 5e-1\n  big: 9007199254740993\n  ports: [8000, 8001]\n"},
+	}
+
+	want := map[string]string{
+		"UPLOAD_LIMIT": "10485760",
+		"UPLOAD_RATIO": "0.5",
+		"UPLOAD_BIG":   "9007199254740993",
+		"UPLOAD_PORTS": "8000,8001",
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.file)
+		if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		source := tt.source(path)
+		if err := source.Load(); err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+
+		for key, value := range want {
+			if got, _, ok := source.Lookup(key); !ok || got != value {
+				t.Errorf("%s: %s = %q, want %q", tt.file, key, got, value)
+			}
+		}
+	}
+}
+
+func TestJSONFileSourceInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	for _, content := range []string{`{"a": }`, `{"a": 1} {"b": 2}`, `[1, 2]`} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := JSONFileSource(path).Load(); err == nil {
+			t.Errorf("Load of %q should fail", content)
+		}
+	}
+}
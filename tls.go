@@ -0,0 +1,159 @@
+package gema
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// tlsReloadInterval limits how often the certificate files are checked for changes
+const tlsReloadInterval = time.Second
+
+// TLSConfig loads the server certificate, and the client CA to verify the client certificates (mTLS).
+// The files are loaded again when they change on disk, so the renewed certificates are used
+// by the new connections without restarting the server:
+//
+//	gema.StartHTTP(":8443", gema.WithTLS(&gema.TLSConfig{
+//		CertFile:     "/etc/tls/tls.crt",
+//		KeyFile:      "/etc/tls/tls.key",
+//		ClientCAFile: "/etc/tls/ca.crt",
+//	}))
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+
+	// ClientCAFile enables the client certificate verification with the CA pool of the file
+	ClientCAFile string
+
+	// ClientAuth defaults to tls.RequireAndVerifyClientCert if ClientCAFile is set
+	ClientAuth tls.ClientAuthType
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	pool     *x509.CertPool
+	modTimes map[string]time.Time
+	checked  time.Time
+}
+
+// Config returns the *tls.Config reloading the certificates, e.g. to be used with credentials.NewTLS
+// in GrpcServerModule, so the handlers can read the client certificate from the peer
+func (t *TLSConfig) Config() *tls.Config {
+	return t.serverConfig("h2", "http/1.1")
+}
+
+func (t *TLSConfig) serverConfig(nextProtos ...string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: nextProtos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool, err := t.current()
+			if err != nil {
+				return nil, err
+			}
+
+			config := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   nextProtos,
+				Certificates: []tls.Certificate{*cert},
+			}
+
+			if pool != nil {
+				config.ClientCAs = pool
+				config.ClientAuth = t.ClientAuth
+				if config.ClientAuth == tls.NoClientCert {
+					config.ClientAuth = tls.RequireAndVerifyClientCert
+				}
+			}
+
+			return config, nil
+		},
+	}
+}
+
+// load loads the files for the first time, so the invalid files fail the start of the server
+func (t *TLSConfig) load() error {
+	_, _, err := t.current()
+	return err
+}
+
+// current returns the loaded certificate and CA pool, loading the files again if they change.
+// If the changed files are invalid, the previous ones are kept
+func (t *TLSConfig) current() (*tls.Certificate, *x509.CertPool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cert != nil && time.Since(t.checked) < tlsReloadInterval {
+		return t.cert, t.pool, nil
+	}
+
+	t.checked = time.Now()
+	if changed := t.filesChanged(); t.cert != nil && !changed {
+		return t.cert, t.pool, nil
+	}
+
+	cert, pool, err := t.read()
+	if err != nil {
+		if t.cert != nil {
+			fmt.Println("[Gema] Failed to reload TLS certificate, keeping the previous one: ", err)
+			return t.cert, t.pool, nil
+		}
+
+		return nil, nil, err
+	}
+
+	t.cert, t.pool = cert, pool
+	return cert, pool, nil
+}
+
+func (t *TLSConfig) read() (*tls.Certificate, *x509.CertPool, error) {
+	cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("[Gema] Failed to load TLS certificate: %w", err)
+	}
+
+	if t.ClientCAFile == "" {
+		return &cert, nil, nil
+	}
+
+	ca, err := os.ReadFile(t.ClientCAFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("[Gema] Failed to load client CA: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, nil, fmt.Errorf("[Gema] No certificate found in client CA %s", t.ClientCAFile)
+	}
+
+	return &cert, pool, nil
+}
+
+// filesChanged must be called while holding the lock
+func (t *TLSConfig) filesChanged() bool {
+	if t.modTimes == nil {
+		t.modTimes = map[string]time.Time{}
+	}
+
+	changed := false
+	for _, file := range []string{t.CertFile, t.KeyFile, t.ClientCAFile} {
+		if file == "" {
+			continue
+		}
+
+		var modTime time.Time
+		if info, err := os.Stat(file); err == nil {
+			modTime = info.ModTime()
+		}
+
+		if last, ok := t.modTimes[file]; !ok || !last.Equal(modTime) {
+			changed = true
+		}
+
+		t.modTimes[file] = modTime
+	}
+
+	return changed
+}
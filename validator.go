@@ -1,18 +1,30 @@
 package gema
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"reflect"
+	"strings"
+	"sync"
 
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	en_translations "github.com/go-playground/validator/v10/translations/en"
 	"github.com/labstack/echo/v4"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// OrderGrpcValidator is the order of the gRPC validation interceptor provided by GrpcServerModule.
+// It runs after the logger and the recovery interceptors
+const OrderGrpcValidator = 100
+
 // to cache the validator (recommended by the docs)
 var uni *ut.UniversalTranslator
 var validate = validator.New(validator.WithRequiredStructEnabled())
@@ -59,6 +71,21 @@ func (b *binder) Bind(i interface{}, c echo.Context) error {
 		return err
 	}
 
+	v, ok := validatorOf(i)
+	if !ok {
+		return nil
+	}
+
+	if err := v.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, translate(err))
+	}
+
+	return nil
+}
+
+// validatorOf returns the Validator of i. If i embeds `gema.Validate` in the first field,
+// it is validated with the struct tags, otherwise i must implement the `gema.Validator` interface
+func validatorOf(i any) (Validator, bool) {
 	val := reflect.ValueOf(i)
 	if val.Kind() == reflect.Ptr {
 		val = val.Elem()
@@ -74,15 +101,105 @@ func (b *binder) Bind(i interface{}, c echo.Context) error {
 	}
 
 	v, ok := i.(Validator)
+	return v, ok
+}
+
+// validationRules caches whether a struct type declares any `validate` tag
+var validationRules sync.Map
+
+func hasValidationRules(typ reflect.Type) bool {
+	if cached, ok := validationRules.Load(typ); ok {
+		return cached.(bool)
+	}
+
+	found := false
+	for i := 0; i < typ.NumField() && !found; i++ {
+		_, found = typ.Field(i).Tag.Lookup("validate")
+	}
+
+	validationRules.Store(typ, found)
+	return found
+}
+
+// validateMessage validates the gRPC request message. Besides the `gema.Validator` and `gema.Validate`,
+// messages carrying the `validate` tags, e.g. injected into the generated code, are validated with the tags
+func validateMessage(msg any) error {
+	v, ok := validatorOf(msg)
 	if !ok {
+		val := reflect.ValueOf(msg)
+		if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+			return nil
+		}
+
+		if !hasValidationRules(val.Elem().Type()) {
+			return nil
+		}
+
+		v = newValidator(msg)
+	}
+
+	err := v.Validate()
+	if err == nil {
 		return nil
 	}
 
-	if err := v.Validate(); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, translate(err))
+	st := status.New(codes.InvalidArgument, translate(err))
+
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return st.Err()
 	}
 
-	return nil
+	badRequest := &errdetails.BadRequest{}
+	for _, fe := range errs {
+		// the namespace is prefixed by the message name, e.g. CreateUserRequest.Address.City
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: fe.Translate(trans),
+		})
+	}
+
+	if detailed, err := st.WithDetails(badRequest); err == nil {
+		st = detailed
+	}
+
+	return st.Err()
+}
+
+type grpcValidator struct{}
+
+func newGrpcValidator() *grpcValidator {
+	return &grpcValidator{}
+}
+
+func (v *grpcValidator) Order() int {
+	return OrderGrpcValidator
+}
+
+func (v *grpcValidator) InterceptUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := validateMessage(req); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (v *grpcValidator) InterceptStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &validatedStream{ss})
+}
+
+// validatedStream validates every message received from the client
+type validatedStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatedStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return validateMessage(m)
 }
 
 func registerCustomBinder(e *echo.Echo) {
@@ -0,0 +1,241 @@
+package gema
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// ConfigEvent is published to the subscribers when a configuration value changes after reload
+type ConfigEvent struct {
+	Key string
+	Old Parser
+	New Parser
+}
+
+type subscription struct {
+	keys []string
+	fn   func(events []ConfigEvent)
+}
+
+// ConfigWatcher re-reads the file backed configuration, i.e. the dotenv files and the FileSource
+// registered with SetConfigSources, when they change on disk or when the process receives SIGHUP.
+// The subscribers are notified for every key whose value changed
+type ConfigWatcher struct {
+	interval time.Duration
+
+	mu            sync.Mutex
+	nextID        int
+	subscriptions map[int]*subscription
+	values        map[string]*parser
+	modTimes      map[string]time.Time
+}
+
+func newConfigWatcher(interval time.Duration) *ConfigWatcher {
+	w := &ConfigWatcher{
+		interval:      interval,
+		subscriptions: map[int]*subscription{},
+		values:        map[string]*parser{},
+		modTimes:      map[string]time.Time{},
+	}
+
+	w.filesChanged()
+	return w
+}
+
+// ConfigWatcherModule provides *ConfigWatcher that checks the configuration files every interval.
+// A zero or negative interval disables the check, so the configuration is only reloaded on SIGHUP
+func ConfigWatcherModule(interval time.Duration) fx.Option {
+	return fx.Module("config_watcher",
+		fx.Provide(func(lc fx.Lifecycle) *ConfigWatcher {
+			w := newConfigWatcher(interval)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			lc.Append(fx.Hook{
+				OnStart: func(_ context.Context) error {
+					go w.run(ctx, done)
+					return nil
+				},
+				OnStop: func(_ context.Context) error {
+					cancel()
+					<-done
+					return nil
+				},
+			})
+
+			return w
+		}),
+		fx.Invoke(func(*ConfigWatcher) {}),
+	)
+}
+
+// Subscribe calls fn whenever the value of key changes. It returns a function to unsubscribe
+func (w *ConfigWatcher) Subscribe(key string, fn func(e ConfigEvent)) (unsubscribe func()) {
+	return w.subscribe([]string{key}, func(events []ConfigEvent) {
+		for _, e := range events {
+			fn(e)
+		}
+	})
+}
+
+func (w *ConfigWatcher) subscribe(keys []string, fn func(events []ConfigEvent)) func() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, key := range keys {
+		if _, ok := w.values[key]; !ok {
+			w.values[key] = lookupEnv(key)
+		}
+	}
+
+	id := w.nextID
+	w.nextID++
+	w.subscriptions[id] = &subscription{keys, fn}
+
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		delete(w.subscriptions, id)
+	}
+}
+
+// WatchConfig subscribes to every key declared by the config struct T (see LoadConfig).
+// Whenever one of them changes, the config is loaded again and passed to fn. If the new
+// configuration is invalid, the error is printed and fn is not called
+func WatchConfig[T any](w *ConfigWatcher, fn func(config T)) (unsubscribe func(), err error) {
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("[Gema] config must be a struct, got %s", typ)
+	}
+
+	var keys []string
+	for _, field := range configFields(typ, "") {
+		keys = append(keys, field.key)
+	}
+
+	return w.subscribe(keys, func(events []ConfigEvent) {
+		var config T
+		if err := LoadConfig(&config); err != nil {
+			fmt.Println("[Gema] Failed to reload configuration: ", err)
+			return
+		}
+
+		fn(config)
+	}), nil
+}
+
+// Reload re-reads the configuration files and notifies the subscribers of the changed values
+func (w *ConfigWatcher) Reload() error {
+	if err := reloadDotenv(); err != nil {
+		return err
+	}
+
+	for _, source := range fileSources() {
+		if err := source.Load(); err != nil {
+			return err
+		}
+	}
+
+	w.mu.Lock()
+	changed := map[string]ConfigEvent{}
+	for key, old := range w.values {
+		current := lookupEnv(key)
+		if current.value != old.value {
+			changed[key] = ConfigEvent{Key: key, Old: old, New: current}
+			w.values[key] = current
+		}
+	}
+
+	var notify []func()
+	for _, sub := range w.subscriptions {
+		var events []ConfigEvent
+		for _, key := range sub.keys {
+			if e, ok := changed[key]; ok {
+				events = append(events, e)
+			}
+		}
+
+		if len(events) > 0 {
+			fn := sub.fn
+			notify = append(notify, func() { fn(events) })
+		}
+	}
+	w.mu.Unlock()
+
+	// the subscribers are called without holding the lock, so they can subscribe or unsubscribe
+	for _, fn := range notify {
+		fn()
+	}
+
+	return nil
+}
+
+func (w *ConfigWatcher) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	// the nil channel never fires, so only SIGHUP triggers the reload
+	var tick <-chan time.Time
+	if w.interval > 0 {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			w.filesChanged()
+		case <-tick:
+			if !w.filesChanged() {
+				continue
+			}
+		}
+
+		if err := w.Reload(); err != nil {
+			fmt.Println("[Gema] Failed to reload configuration: ", err)
+		}
+	}
+}
+
+// filesChanged reports whether any of the configuration files is modified, created or removed
+// since the last check
+func (w *ConfigWatcher) filesChanged() bool {
+	files := dotenvWatchFiles()
+	for _, source := range fileSources() {
+		files = append(files, source.Files()...)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	changed := false
+	for _, file := range files {
+		var modTime time.Time
+		if info, err := os.Stat(file); err == nil {
+			modTime = info.ModTime()
+		}
+
+		if last, ok := w.modTimes[file]; ok && !last.Equal(modTime) {
+			changed = true
+		}
+
+		w.modTimes[file] = modTime
+	}
+
+	return changed
+}
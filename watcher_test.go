@@ -0,0 +1,32 @@
+package gema
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+func TestConfigWatcherWithoutInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		var watcher *ConfigWatcher
+		app := fxtest.New(t, fx.NopLogger, ConfigWatcherModule(interval), fx.Populate(&watcher))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		if err := app.Start(ctx); err != nil {
+			t.Fatalf("Start with interval %v: %v", interval, err)
+		}
+
+		if err := watcher.Reload(); err != nil {
+			t.Errorf("Reload with interval %v: %v", interval, err)
+		}
+
+		if err := app.Stop(ctx); err != nil {
+			t.Errorf("Stop with interval %v: %v", interval, err)
+		}
+
+		cancel()
+	}
+}